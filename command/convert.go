@@ -2,6 +2,7 @@ package command
 
 import (
 	"flag"
+	"fmt"
 	"strings"
 
 	"github.com/sloppyio/sloppose/pkg/converter"
@@ -15,9 +16,31 @@ Usage: sloppose convert [options] [files]
 
 Options:
   -o              output path, defaults to working directory
-  -projectname    sets the projectname, defaults to working directory
+  -projectname    sets the projectname, defaults to the compose file directory
+  -profile        enables the services of a profile, may be repeated,
+                  defaults to COMPOSE_PROFILES
+  -services       converts only the given comma separated services and the
+                  services they depend on or refer to by environment values
+  -exclude        drops the given comma separated services
+  -no-env-passthrough
+                  rejects environment keys without a value instead of
+                  taking their value from the host environment
+  -ports          "first" converts the primary port of each service only
+                  and reports the others, "all" converts all ports to
+                  port_mappings, defaults to "first"
+  -volume-size    size of volumes without a size of their own, e.g. 8GB
+  -default-memory memory of services without a memory limit or
+                  reservation, e.g. 256M
+  -round-memory   rounds memory up to the sizes supported by sloppy.io
 
-Defaults to docker-compose.yml if no files are given.
+Without files, the files listed by COMPOSE_FILE (separated by
+COMPOSE_PATH_SEPARATOR) are used. Otherwise the first of compose.yaml,
+compose.yml, docker-compose.yaml and docker-compose.yml found in the working
+directory or its parents, merged with a docker-compose.override.yml (or
+compose.override.yaml) next to it.
+Multiple files are merged in the given order, later files override earlier ones.
+Variables like ${VAR} are substituted from the environment and a .env file
+next to the first compose file.
 Converts a docker-compose.yml to a sloppy.io compatible yml format.
 `
 	return strings.TrimSpace(text)
@@ -28,33 +51,66 @@ func (c *Convert) Synopsis() string {
 }
 
 func (c *Convert) Run(args []string) error {
-	var output, projectName string
+	var output string
+	options := &converter.ComposeOptions{}
+	sloppyOptions := &converter.SloppyOptions{}
 	flagSet := &flag.FlagSet{}
 	flagSet.StringVar(&output, "o", "", "-o path/file.yml")
-	flagSet.StringVar(&projectName, "projectname", "", "-projectname yourProjectName")
+	flagSet.StringVar(&options.ProjectName, "projectname", "", "-projectname yourProjectName")
+	flagSet.Var((*stringsFlag)(&options.Profiles), "profile", "-profile debug")
+	flagSet.Var((*listFlag)(&options.Services), "services", "-services api,worker")
+	flagSet.Var((*listFlag)(&options.Exclude), "exclude", "-exclude admin")
+	flagSet.BoolVar(&options.ForbidEnvPassthrough, "no-env-passthrough", false, "-no-env-passthrough")
+	flagSet.StringVar(&sloppyOptions.PortMode, "ports", converter.PortModeFirst, "-ports all")
+	flagSet.StringVar(&sloppyOptions.VolumeSize, "volume-size", "", "-volume-size 8GB")
+	flagSet.StringVar(&sloppyOptions.DefaultMemory, "default-memory", "", "-default-memory 256M")
+	flagSet.BoolVar(&sloppyOptions.RoundMemory, "round-memory", false, "-round-memory")
 	err := flagSet.Parse(args)
 	if err != nil {
 		return err
 	}
 
 	reader := &converter.ComposeReader{}
-	buf, err := reader.Read(flagSet.Args()[0])
-	if err != nil {
-		return err
+	filenames := flagSet.Args()
+	if len(filenames) == 0 {
+		filenames, err = reader.DefaultFiles()
+		if err != nil {
+			return err
+		}
+	}
+	var sources []*converter.ComposeSource
+	for _, filename := range filenames {
+		source, err := reader.ReadSource(filename)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, source)
 	}
 
-	cf, err := converter.NewComposeFile(buf, projectName)
+	cf, err := converter.NewComposeFileWithOptions(sources, options)
 	if err != nil {
 		return err
 	}
+	for _, name := range cf.UnsetVariables {
+		fmt.Printf("The %q variable is not set. Defaulting to a blank string.\n", name)
+	}
+	for _, warning := range cf.Warnings {
+		fmt.Println(warning)
+	}
+	for _, v := range cf.UnresolvedEnvironment {
+		fmt.Printf("The %q environment variable of service %q is not set on the host. Omitting it.\n", v.Name, v.Service)
+	}
 
-	sf, err := converter.NewSloppyFile(cf)
+	sf, err := converter.NewSloppyFileWithOptions(cf, sloppyOptions)
 	if err != nil {
 		return err
 	}
 
 	linker := &converter.Linker{}
 	err = linker.Resolve(cf, sf)
+	for _, warning := range sf.Warnings {
+		fmt.Println(warning)
+	}
 	if err != nil {
 		return err
 	}
@@ -65,3 +121,31 @@ func (c *Convert) Run(args []string) error {
 	writer := &converter.YAMLWriter{}
 	return writer.WriteFile(sf, output)
 }
+
+// stringsFlag collects the values of a repeatable flag.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// listFlag collects the comma separated values of a repeatable flag.
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*f = append(*f, v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+// Code generated by 'github.com/sevenval/structgen'. DO NOT EDIT.
+
+package config
+
+type BlkioConfigSpec struct {
+	DeviceReadBps   []interface{} `json:"device_read_bps,omitempty"`
+	DeviceReadIops  []interface{} `json:"device_read_iops,omitempty"`
+	DeviceWriteBps  []interface{} `json:"device_write_bps,omitempty"`
+	DeviceWriteIops []interface{} `json:"device_write_iops,omitempty"`
+	Weight          interface{}   `json:"weight,omitempty"` // integer,string
+	WeightDevice    []interface{} `json:"weight_device,omitempty"`
+}
+
+type BlkioLimitSpec struct {
+	Path string      `json:"path,omitempty"`
+	Rate interface{} `json:"rate,omitempty"` // integer,string
+}
+
+type BlkioWeightSpec struct {
+	Path   string      `json:"path,omitempty"`
+	Weight interface{} `json:"weight,omitempty"` // integer,string
+}
+
+type ConfigSpec struct {
+	Content        string      `json:"content,omitempty"`
+	Environment    string      `json:"environment,omitempty"`
+	External       interface{} `json:"external,omitempty"` // boolean,string,object
+	File           string      `json:"file,omitempty"`
+	Labels         Mapping     `json:"labels,omitempty"`
+	Name           string      `json:"name,omitempty"`
+	TemplateDriver string      `json:"template_driver,omitempty"`
+}
+
+type CredentialSpecSpec struct {
+	Config   string `json:"config,omitempty"`
+	File     string `json:"file,omitempty"`
+	Registry string `json:"registry,omitempty"`
+}
+
+type DeploymentSpec struct {
+	EndpointMode   string              `json:"endpoint_mode,omitempty"`
+	Labels         Mapping             `json:"labels,omitempty"`
+	Mode           string              `json:"mode,omitempty"`
+	Placement      *PlacementSpec      `json:"placement,omitempty"`
+	Replicas       interface{}         `json:"replicas,omitempty"` // integer,string
+	Resources      *ResourcesSpec      `json:"resources,omitempty"`
+	RestartPolicy  *RestartPolicySpec  `json:"restart_policy,omitempty"`
+	RollbackConfig *RollbackConfigSpec `json:"rollback_config,omitempty"`
+	UpdateConfig   *UpdateConfigSpec   `json:"update_config,omitempty"`
+}
+
+type DevelopmentSpec struct {
+	Watch []*WatchSpec `json:"watch,omitempty"`
+}
+
+type DiscreteResourceSpecSpec struct {
+	Kind  string      `json:"kind,omitempty"`
+	Value interface{} `json:"value,omitempty"` // number,string
+}
+
+type DockerComposeSpec struct {
+	Configs  map[string]*ConfigSpec  `json:"configs,omitempty"`
+	Include  []interface{}           `json:"include,omitempty"`
+	Models   map[string]*ModelSpec   `json:"models,omitempty"`
+	Name     string                  `json:"name,omitempty"`
+	Networks map[string]*NetworkSpec `json:"networks,omitempty"`
+	Secrets  map[string]*SecretSpec  `json:"secrets,omitempty"`
+	Services map[string]*ServiceSpec `json:"services,omitempty"`
+	Version  string                  `json:"version,omitempty"`
+	Volumes  map[string]*VolumeSpec  `json:"volumes,omitempty"`
+	XSloppy  Extension               `json:"x-sloppy,omitempty"`
+}
+
+type ExternalSpec struct {
+	Name string `json:"name,omitempty"`
+}
+
+type GenericResourcesSpec struct {
+	DiscreteResourceSpec *DiscreteResourceSpecSpec `json:"discrete_resource_spec,omitempty"`
+}
+
+type HealthcheckSpec struct {
+	Disable       interface{}     `json:"disable,omitempty"` // boolean,string
+	Interval      string          `json:"interval,omitempty"`
+	Retries       interface{}     `json:"retries,omitempty"` // number,string
+	StartInterval string          `json:"start_interval,omitempty"`
+	StartPeriod   string          `json:"start_period,omitempty"`
+	Test          HealthcheckTest `json:"test,omitempty"`
+	Timeout       string          `json:"timeout,omitempty"`
+}
+
+type IpamSpec struct {
+	Config  []*ConfigSpec `json:"config,omitempty"`
+	Driver  string        `json:"driver,omitempty"`
+	Options string        `json:"options,omitempty"`
+}
+
+type LimitsSpec struct {
+	Cpus   interface{} `json:"cpus,omitempty"` // number,string
+	Memory string      `json:"memory,omitempty"`
+	Pids   interface{} `json:"pids,omitempty"` // integer,string
+}
+
+type LoggingSpec struct {
+	Driver  string      `json:"driver,omitempty"`
+	Options interface{} `json:"options,omitempty"`
+}
+
+type ModelSpec struct {
+	ContextSize  int      `json:"context_size,omitempty"`
+	Model        string   `json:"model"`
+	Name         string   `json:"name,omitempty"`
+	RuntimeFlags []string `json:"runtime_flags,omitempty"`
+}
+
+type NetworkSpec struct {
+	Attachable interface{} `json:"attachable,omitempty"` // boolean,string
+	Driver     string      `json:"driver,omitempty"`
+	DriverOpts interface{} `json:"driver_opts,omitempty"`
+	EnableIpv4 interface{} `json:"enable_ipv4,omitempty"` // boolean,string
+	EnableIpv6 interface{} `json:"enable_ipv6,omitempty"` // boolean,string
+	External   interface{} `json:"external,omitempty"`    // boolean,string,object
+	Internal   interface{} `json:"internal,omitempty"`    // boolean,string
+	Ipam       *IpamSpec   `json:"ipam,omitempty"`
+	Labels     Mapping     `json:"labels,omitempty"`
+	Name       string      `json:"name,omitempty"`
+}
+
+type PlacementSpec struct {
+	Constraints        []string           `json:"constraints,omitempty"`
+	MaxReplicasPerNode interface{}        `json:"max_replicas_per_node,omitempty"` // integer,string
+	Preferences        []*PreferencesSpec `json:"preferences,omitempty"`
+}
+
+type PreferencesSpec struct {
+	Spread string `json:"spread,omitempty"`
+}
+
+type ProviderSpec struct {
+	Options interface{} `json:"options,omitempty"`
+	Type    string      `json:"type"`
+}
+
+type ReservationsSpec struct {
+	Cpus             interface{} `json:"cpus,omitempty"` // number,string
+	Devices          interface{} `json:"devices,omitempty"`
+	GenericResources interface{} `json:"generic_resources,omitempty"`
+	Memory           string      `json:"memory,omitempty"`
+}
+
+type ResourcesSpec struct {
+	Limits       *LimitsSpec       `json:"limits,omitempty"`
+	Reservations *ReservationsSpec `json:"reservations,omitempty"`
+}
+
+type RestartPolicySpec struct {
+	Condition   string      `json:"condition,omitempty"`
+	Delay       string      `json:"delay,omitempty"`
+	MaxAttempts interface{} `json:"max_attempts,omitempty"` // integer,string
+	Window      string      `json:"window,omitempty"`
+}
+
+type RollbackConfigSpec struct {
+	Delay           string      `json:"delay,omitempty"`
+	FailureAction   string      `json:"failure_action,omitempty"`
+	MaxFailureRatio interface{} `json:"max_failure_ratio,omitempty"` // number,string
+	Monitor         string      `json:"monitor,omitempty"`
+	Order           string      `json:"order,omitempty"`
+	Parallelism     interface{} `json:"parallelism,omitempty"` // integer,string
+}
+
+type SecretSpec struct {
+	Driver         string      `json:"driver,omitempty"`
+	DriverOpts     interface{} `json:"driver_opts,omitempty"`
+	Environment    string      `json:"environment,omitempty"`
+	External       interface{} `json:"external,omitempty"` // boolean,string,object
+	File           string      `json:"file,omitempty"`
+	Labels         Mapping     `json:"labels,omitempty"`
+	Name           string      `json:"name,omitempty"`
+	TemplateDriver string      `json:"template_driver,omitempty"`
+}
+
+type ServiceHookSpec struct {
+	Command     interface{} `json:"command"`
+	Environment interface{} `json:"environment,omitempty"`
+	Privileged  interface{} `json:"privileged,omitempty"` // boolean,string
+	User        string      `json:"user,omitempty"`
+	WorkingDir  string      `json:"working_dir,omitempty"`
+}
+
+type ServiceSpec struct {
+	Annotations       interface{}           `json:"annotations,omitempty"`
+	Attach            interface{}           `json:"attach,omitempty"` // boolean,string
+	BlkioConfig       *BlkioConfigSpec      `json:"blkio_config,omitempty"`
+	Build             interface{}           `json:"build,omitempty"` // string,object
+	CapAdd            []string              `json:"cap_add,omitempty"`
+	CapDrop           []string              `json:"cap_drop,omitempty"`
+	Cgroup            string                `json:"cgroup,omitempty"`
+	CgroupParent      string                `json:"cgroup_parent,omitempty"`
+	Command           ShellCommand          `json:"command,omitempty"`
+	Configs           ServiceFileReferences `json:"configs,omitempty"`
+	ContainerName     string                `json:"container_name,omitempty"`
+	CpuCount          interface{}           `json:"cpu_count,omitempty"`      // string,integer
+	CpuPercent        interface{}           `json:"cpu_percent,omitempty"`    // string,integer
+	CpuPeriod         interface{}           `json:"cpu_period,omitempty"`     // number,string
+	CpuQuota          interface{}           `json:"cpu_quota,omitempty"`      // number,string
+	CpuRtPeriod       interface{}           `json:"cpu_rt_period,omitempty"`  // number,string
+	CpuRtRuntime      interface{}           `json:"cpu_rt_runtime,omitempty"` // number,string
+	CpuShares         interface{}           `json:"cpu_shares,omitempty"`     // number,string
+	Cpus              interface{}           `json:"cpus,omitempty"`           // number,string
+	Cpuset            string                `json:"cpuset,omitempty"`
+	CredentialSpec    *CredentialSpecSpec   `json:"credential_spec,omitempty"`
+	DependsOn         DependsOn             `json:"depends_on,omitempty"`
+	Deploy            *DeploymentSpec       `json:"deploy,omitempty"`
+	Develop           *DevelopmentSpec      `json:"develop,omitempty"`
+	DeviceCgroupRules interface{}           `json:"device_cgroup_rules,omitempty"`
+	Devices           []interface{}         `json:"devices,omitempty"` // string,object
+	Dns               StringOrList          `json:"dns,omitempty"`
+	DnsOpt            []string              `json:"dns_opt,omitempty"`
+	DnsSearch         StringOrList          `json:"dns_search,omitempty"`
+	Domainname        string                `json:"domainname,omitempty"`
+	Entrypoint        ShellCommand          `json:"entrypoint,omitempty"`
+	EnvFile           EnvFiles              `json:"env_file,omitempty"`
+	Environment       MappingWithEquals     `json:"environment,omitempty"`
+	Expose            []interface{}         `json:"expose,omitempty"`  // string,number
+	Extends           interface{}           `json:"extends,omitempty"` // string,object
+	ExternalLinks     []string              `json:"external_links,omitempty"`
+	ExtraHosts        interface{}           `json:"extra_hosts,omitempty"`
+	Gpus              interface{}           `json:"gpus,omitempty"`
+	GroupAdd          []interface{}         `json:"group_add,omitempty"` // string,number
+	Healthcheck       *HealthcheckSpec      `json:"healthcheck,omitempty"`
+	Hostname          string                `json:"hostname,omitempty"`
+	Image             string                `json:"image,omitempty"`
+	Init              interface{}           `json:"init,omitempty"` // boolean,string
+	Ipc               string                `json:"ipc,omitempty"`
+	Isolation         string                `json:"isolation,omitempty"`
+	LabelFile         interface{}           `json:"label_file,omitempty"`
+	Labels            Mapping               `json:"labels,omitempty"`
+	Links             []string              `json:"links,omitempty"`
+	Logging           *LoggingSpec          `json:"logging,omitempty"`
+	MacAddress        string                `json:"mac_address,omitempty"`
+	MemLimit          interface{}           `json:"mem_limit,omitempty"`       // number,string
+	MemReservation    interface{}           `json:"mem_reservation,omitempty"` // string,integer
+	MemSwappiness     interface{}           `json:"mem_swappiness,omitempty"`  // integer,string
+	MemswapLimit      interface{}           `json:"memswap_limit,omitempty"`   // number,string
+	Models            interface{}           `json:"models,omitempty"`          // object
+	NetworkMode       string                `json:"network_mode,omitempty"`
+	Networks          interface{}           `json:"networks,omitempty"`         // object
+	OomKillDisable    interface{}           `json:"oom_kill_disable,omitempty"` // boolean,string
+	OomScoreAdj       interface{}           `json:"oom_score_adj,omitempty"`    // string,integer
+	Pid               interface{}           `json:"pid,omitempty"`              // string,null
+	PidsLimit         interface{}           `json:"pids_limit,omitempty"`       // number,string
+	Platform          string                `json:"platform,omitempty"`
+	Ports             ServicePorts          `json:"ports,omitempty"`
+	PostStart         []interface{}         `json:"post_start,omitempty"`
+	PreStop           []interface{}         `json:"pre_stop,omitempty"`
+	Privileged        interface{}           `json:"privileged,omitempty"` // boolean,string
+	Profiles          StringOrList          `json:"profiles,omitempty"`
+	Provider          *ProviderSpec         `json:"provider,omitempty"`
+	PullPolicy        string                `json:"pull_policy,omitempty"`
+	PullRefreshAfter  string                `json:"pull_refresh_after,omitempty"`
+	ReadOnly          interface{}           `json:"read_only,omitempty"` // boolean,string
+	Restart           string                `json:"restart,omitempty"`
+	Runtime           string                `json:"runtime,omitempty"`
+	Scale             interface{}           `json:"scale,omitempty"` // integer,string
+	Secrets           ServiceFileReferences `json:"secrets,omitempty"`
+	SecurityOpt       []string              `json:"security_opt,omitempty"`
+	ShmSize           interface{}           `json:"shm_size,omitempty"`   // number,string
+	StdinOpen         interface{}           `json:"stdin_open,omitempty"` // boolean,string
+	StopGracePeriod   string                `json:"stop_grace_period,omitempty"`
+	StopSignal        string                `json:"stop_signal,omitempty"`
+	StorageOpt        interface{}           `json:"storage_opt,omitempty"` // object
+	Sysctls           Mapping               `json:"sysctls,omitempty"`
+	Tmpfs             StringOrList          `json:"tmpfs,omitempty"`
+	Tty               interface{}           `json:"tty,omitempty"` // boolean,string
+	Ulimits           Ulimits               `json:"ulimits,omitempty"`
+	UseApiSocket      bool                  `json:"use_api_socket,omitempty"`
+	User              string                `json:"user,omitempty"`
+	UsernsMode        string                `json:"userns_mode,omitempty"`
+	Uts               string                `json:"uts,omitempty"`
+	Volumes           ServiceVolumes        `json:"volumes,omitempty"`
+	VolumesFrom       []string              `json:"volumes_from,omitempty"`
+	WorkingDir        string                `json:"working_dir,omitempty"`
+	XSloppy           Extension             `json:"x-sloppy,omitempty"`
+}
+
+type UpdateConfigSpec struct {
+	Delay           string      `json:"delay,omitempty"`
+	FailureAction   string      `json:"failure_action,omitempty"`
+	MaxFailureRatio interface{} `json:"max_failure_ratio,omitempty"` // number,string
+	Monitor         string      `json:"monitor,omitempty"`
+	Order           string      `json:"order,omitempty"`
+	Parallelism     interface{} `json:"parallelism,omitempty"` // integer,string
+}
+
+type VolumeSpec struct {
+	Driver     string      `json:"driver,omitempty"`
+	DriverOpts interface{} `json:"driver_opts,omitempty"`
+	External   interface{} `json:"external,omitempty"` // boolean,string,object
+	Labels     Mapping     `json:"labels,omitempty"`
+	Name       string      `json:"name,omitempty"`
+}
+
+type WatchSpec struct {
+	Action      string           `json:"action,omitempty"`
+	Exec        *ServiceHookSpec `json:"exec,omitempty"`
+	Ignore      interface{}      `json:"ignore,omitempty"`
+	Include     interface{}      `json:"include,omitempty"`
+	InitialSync bool             `json:"initial_sync,omitempty"`
+	Path        string           `json:"path,omitempty"`
+	Target      string           `json:"target,omitempty"`
+}
@@ -0,0 +1,157 @@
+// Code generated by 'github.com/sevenval/structgen'. DO NOT EDIT.
+
+package config
+
+type BlkioConfigV2 struct {
+	DeviceReadBps   []interface{} `json:"device_read_bps,omitempty"`
+	DeviceReadIops  []interface{} `json:"device_read_iops,omitempty"`
+	DeviceWriteBps  []interface{} `json:"device_write_bps,omitempty"`
+	DeviceWriteIops []interface{} `json:"device_write_iops,omitempty"`
+	Weight          int           `json:"weight,omitempty"`
+	WeightDevice    []interface{} `json:"weight_device,omitempty"`
+}
+
+type BlkioLimitV2 struct {
+	Path string      `json:"path,omitempty"`
+	Rate interface{} `json:"rate,omitempty"` // integer,string
+}
+
+type BlkioWeightV2 struct {
+	Path   string `json:"path,omitempty"`
+	Weight int    `json:"weight,omitempty"`
+}
+
+type ConfigV2 struct {
+	Gateway string `json:"gateway,omitempty"`
+	IpRange string `json:"ip_range,omitempty"`
+	Subnet  string `json:"subnet,omitempty"`
+}
+
+type DockerComposeV2 struct {
+	Networks map[string]*NetworkV2 `json:"networks,omitempty"`
+	Services map[string]*ServiceV2 `json:"services,omitempty"`
+	Version  string                `json:"version,omitempty"`
+	Volumes  map[string]*VolumeV2  `json:"volumes,omitempty"`
+	XSloppy  Extension             `json:"x-sloppy,omitempty"`
+}
+
+type ExternalV2 struct {
+	Name string `json:"name,omitempty"`
+}
+
+type HealthcheckV2 struct {
+	Disable     bool            `json:"disable,omitempty"`
+	Interval    string          `json:"interval,omitempty"`
+	Retries     float64         `json:"retries,omitempty"`
+	StartPeriod string          `json:"start_period,omitempty"`
+	Test        HealthcheckTest `json:"test,omitempty"`
+	Timeout     string          `json:"timeout,omitempty"`
+}
+
+type IpamV2 struct {
+	Config  []*ConfigV2 `json:"config,omitempty"`
+	Driver  string      `json:"driver,omitempty"`
+	Options string      `json:"options,omitempty"`
+}
+
+type LoggingV2 struct {
+	Driver  string      `json:"driver,omitempty"`
+	Options interface{} `json:"options,omitempty"`
+}
+
+type NetworkV2 struct {
+	Driver     string      `json:"driver,omitempty"`
+	DriverOpts interface{} `json:"driver_opts,omitempty"`
+	EnableIpv6 bool        `json:"enable_ipv6,omitempty"`
+	External   interface{} `json:"external,omitempty"` // boolean,object
+	Internal   bool        `json:"internal,omitempty"`
+	Ipam       *IpamV2     `json:"ipam,omitempty"`
+	Labels     Mapping     `json:"labels,omitempty"`
+	Name       string      `json:"name,omitempty"`
+}
+
+type ServiceV2 struct {
+	BlkioConfig       *BlkioConfigV2    `json:"blkio_config,omitempty"`
+	Build             interface{}       `json:"build,omitempty"` // string,object
+	CapAdd            []string          `json:"cap_add,omitempty"`
+	CapDrop           []string          `json:"cap_drop,omitempty"`
+	CgroupParent      string            `json:"cgroup_parent,omitempty"`
+	Command           ShellCommand      `json:"command,omitempty"`
+	ContainerName     string            `json:"container_name,omitempty"`
+	CpuCount          int               `json:"cpu_count,omitempty"`
+	CpuPercent        int               `json:"cpu_percent,omitempty"`
+	CpuPeriod         interface{}       `json:"cpu_period,omitempty"`     // number,string
+	CpuQuota          interface{}       `json:"cpu_quota,omitempty"`      // number,string
+	CpuRtPeriod       interface{}       `json:"cpu_rt_period,omitempty"`  // number,string
+	CpuRtRuntime      interface{}       `json:"cpu_rt_runtime,omitempty"` // number,string
+	CpuShares         interface{}       `json:"cpu_shares,omitempty"`     // number,string
+	Cpus              float64           `json:"cpus,omitempty"`
+	Cpuset            string            `json:"cpuset,omitempty"`
+	DependsOn         DependsOn         `json:"depends_on,omitempty"`
+	DeviceCgroupRules interface{}       `json:"device_cgroup_rules,omitempty"`
+	Devices           []string          `json:"devices,omitempty"`
+	Dns               StringOrList      `json:"dns,omitempty"`
+	DnsOpt            []string          `json:"dns_opt,omitempty"`
+	DnsSearch         StringOrList      `json:"dns_search,omitempty"`
+	Domainname        string            `json:"domainname,omitempty"`
+	Entrypoint        ShellCommand      `json:"entrypoint,omitempty"`
+	EnvFile           EnvFiles          `json:"env_file,omitempty"`
+	Environment       MappingWithEquals `json:"environment,omitempty"`
+	Expose            []interface{}     `json:"expose,omitempty"`  // string,number
+	Extends           interface{}       `json:"extends,omitempty"` // string,object
+	ExternalLinks     []string          `json:"external_links,omitempty"`
+	ExtraHosts        interface{}       `json:"extra_hosts,omitempty"`
+	GroupAdd          []interface{}     `json:"group_add,omitempty"` // string,number
+	Healthcheck       *HealthcheckV2    `json:"healthcheck,omitempty"`
+	Hostname          string            `json:"hostname,omitempty"`
+	Image             string            `json:"image,omitempty"`
+	Init              interface{}       `json:"init,omitempty"` // boolean,string
+	Ipc               string            `json:"ipc,omitempty"`
+	Isolation         string            `json:"isolation,omitempty"`
+	Labels            Mapping           `json:"labels,omitempty"`
+	Links             []string          `json:"links,omitempty"`
+	Logging           *LoggingV2        `json:"logging,omitempty"`
+	MacAddress        string            `json:"mac_address,omitempty"`
+	MemLimit          interface{}       `json:"mem_limit,omitempty"`       // number,string
+	MemReservation    interface{}       `json:"mem_reservation,omitempty"` // string,integer
+	MemSwappiness     int               `json:"mem_swappiness,omitempty"`
+	MemswapLimit      interface{}       `json:"memswap_limit,omitempty"` // number,string
+	NetworkMode       string            `json:"network_mode,omitempty"`
+	Networks          interface{}       `json:"networks,omitempty"` // object
+	OomKillDisable    bool              `json:"oom_kill_disable,omitempty"`
+	OomScoreAdj       int               `json:"oom_score_adj,omitempty"`
+	Pid               interface{}       `json:"pid,omitempty"`        // string,null
+	PidsLimit         interface{}       `json:"pids_limit,omitempty"` // number,string
+	Platform          string            `json:"platform,omitempty"`
+	Ports             ServicePorts      `json:"ports,omitempty"`
+	Privileged        bool              `json:"privileged,omitempty"`
+	ReadOnly          bool              `json:"read_only,omitempty"`
+	Restart           string            `json:"restart,omitempty"`
+	Runtime           string            `json:"runtime,omitempty"`
+	Scale             int               `json:"scale,omitempty"`
+	SecurityOpt       []string          `json:"security_opt,omitempty"`
+	ShmSize           interface{}       `json:"shm_size,omitempty"` // number,string
+	StdinOpen         bool              `json:"stdin_open,omitempty"`
+	StopGracePeriod   string            `json:"stop_grace_period,omitempty"`
+	StopSignal        string            `json:"stop_signal,omitempty"`
+	StorageOpt        interface{}       `json:"storage_opt,omitempty"`
+	Sysctls           Mapping           `json:"sysctls,omitempty"`
+	Tmpfs             StringOrList      `json:"tmpfs,omitempty"`
+	Tty               bool              `json:"tty,omitempty"`
+	Ulimits           Ulimits           `json:"ulimits,omitempty"`
+	User              string            `json:"user,omitempty"`
+	UsernsMode        string            `json:"userns_mode,omitempty"`
+	VolumeDriver      string            `json:"volume_driver,omitempty"`
+	Volumes           ServiceVolumes    `json:"volumes,omitempty"`
+	VolumesFrom       []string          `json:"volumes_from,omitempty"`
+	WorkingDir        string            `json:"working_dir,omitempty"`
+	XSloppy           Extension         `json:"x-sloppy,omitempty"`
+}
+
+type VolumeV2 struct {
+	Driver     string      `json:"driver,omitempty"`
+	DriverOpts interface{} `json:"driver_opts,omitempty"`
+	External   interface{} `json:"external,omitempty"` // boolean,object
+	Labels     Mapping     `json:"labels,omitempty"`
+	Name       string      `json:"name,omitempty"`
+}
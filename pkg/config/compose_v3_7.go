@@ -0,0 +1,201 @@
+// Code generated by 'github.com/sevenval/structgen'. DO NOT EDIT.
+
+package config
+
+type ConfigV37 struct {
+	External interface{} `json:"external,omitempty"` // boolean,object
+	File     string      `json:"file,omitempty"`
+	Labels   Mapping     `json:"labels,omitempty"`
+	Name     string      `json:"name,omitempty"`
+}
+
+type CredentialSpecV37 struct {
+	File     string `json:"file,omitempty"`
+	Registry string `json:"registry,omitempty"`
+}
+
+type DeploymentV37 struct {
+	EndpointMode   string             `json:"endpoint_mode,omitempty"`
+	Labels         Mapping            `json:"labels,omitempty"`
+	Mode           string             `json:"mode,omitempty"`
+	Placement      *PlacementV37      `json:"placement,omitempty"`
+	Replicas       int                `json:"replicas,omitempty"`
+	Resources      *ResourcesV37      `json:"resources,omitempty"`
+	RestartPolicy  *RestartPolicyV37  `json:"restart_policy,omitempty"`
+	RollbackConfig *RollbackConfigV37 `json:"rollback_config,omitempty"`
+	UpdateConfig   *UpdateConfigV37   `json:"update_config,omitempty"`
+}
+
+type DiscreteResourceSpecV37 struct {
+	Kind  string  `json:"kind,omitempty"`
+	Value float64 `json:"value,omitempty"`
+}
+
+type DockerComposeV37 struct {
+	Configs  map[string]*ConfigV37  `json:"configs,omitempty"`
+	Networks map[string]*NetworkV37 `json:"networks,omitempty"`
+	Secrets  map[string]*SecretV37  `json:"secrets,omitempty"`
+	Services map[string]*ServiceV37 `json:"services,omitempty"`
+	Version  string                 `json:"version"`
+	Volumes  map[string]*VolumeV37  `json:"volumes,omitempty"`
+	XSloppy  Extension              `json:"x-sloppy,omitempty"`
+}
+
+type ExternalV37 struct {
+	Name string `json:"name,omitempty"`
+}
+
+type GenericResourcesV37 struct {
+	DiscreteResourceSpec *DiscreteResourceSpecV37 `json:"discrete_resource_spec,omitempty"`
+}
+
+type HealthcheckV37 struct {
+	Disable     bool            `json:"disable,omitempty"`
+	Interval    string          `json:"interval,omitempty"`
+	Retries     float64         `json:"retries,omitempty"`
+	StartPeriod string          `json:"start_period,omitempty"`
+	Test        HealthcheckTest `json:"test,omitempty"`
+	Timeout     string          `json:"timeout,omitempty"`
+}
+
+type IpamV37 struct {
+	Config []*ConfigV37 `json:"config,omitempty"`
+	Driver string       `json:"driver,omitempty"`
+}
+
+type LimitsV37 struct {
+	Cpus   string `json:"cpus,omitempty"`
+	Memory string `json:"memory,omitempty"`
+}
+
+type LoggingV37 struct {
+	Driver  string      `json:"driver,omitempty"`
+	Options interface{} `json:"options,omitempty"`
+}
+
+type NetworkV37 struct {
+	Attachable bool        `json:"attachable,omitempty"`
+	Driver     string      `json:"driver,omitempty"`
+	DriverOpts interface{} `json:"driver_opts,omitempty"`
+	External   interface{} `json:"external,omitempty"` // boolean,object
+	Internal   bool        `json:"internal,omitempty"`
+	Ipam       *IpamV37    `json:"ipam,omitempty"`
+	Labels     Mapping     `json:"labels,omitempty"`
+	Name       string      `json:"name,omitempty"`
+}
+
+type PlacementV37 struct {
+	Constraints []string          `json:"constraints,omitempty"`
+	Preferences []*PreferencesV37 `json:"preferences,omitempty"`
+}
+
+type PreferencesV37 struct {
+	Spread string `json:"spread,omitempty"`
+}
+
+type ReservationsV37 struct {
+	Cpus             string                 `json:"cpus,omitempty"`
+	GenericResources []*GenericResourcesV37 `json:"generic_resources,omitempty"`
+	Memory           string                 `json:"memory,omitempty"`
+}
+
+type ResourcesV37 struct {
+	Limits       *LimitsV37       `json:"limits,omitempty"`
+	Reservations *ReservationsV37 `json:"reservations,omitempty"`
+}
+
+type RestartPolicyV37 struct {
+	Condition   string `json:"condition,omitempty"`
+	Delay       string `json:"delay,omitempty"`
+	MaxAttempts int    `json:"max_attempts,omitempty"`
+	Window      string `json:"window,omitempty"`
+}
+
+type RollbackConfigV37 struct {
+	Delay           string  `json:"delay,omitempty"`
+	FailureAction   string  `json:"failure_action,omitempty"`
+	MaxFailureRatio float64 `json:"max_failure_ratio,omitempty"`
+	Monitor         string  `json:"monitor,omitempty"`
+	Order           string  `json:"order,omitempty"`
+	Parallelism     int     `json:"parallelism,omitempty"`
+}
+
+type SecretV37 struct {
+	External interface{} `json:"external,omitempty"` // boolean,object
+	File     string      `json:"file,omitempty"`
+	Labels   Mapping     `json:"labels,omitempty"`
+	Name     string      `json:"name,omitempty"`
+}
+
+type ServiceV37 struct {
+	Build           interface{}           `json:"build,omitempty"` // string,object
+	CapAdd          []string              `json:"cap_add,omitempty"`
+	CapDrop         []string              `json:"cap_drop,omitempty"`
+	CgroupParent    string                `json:"cgroup_parent,omitempty"`
+	Command         ShellCommand          `json:"command,omitempty"`
+	Configs         ServiceFileReferences `json:"configs,omitempty"`
+	ContainerName   string                `json:"container_name,omitempty"`
+	CredentialSpec  *CredentialSpecV37    `json:"credential_spec,omitempty"`
+	DependsOn       DependsOn             `json:"depends_on,omitempty"`
+	Deploy          *DeploymentV37        `json:"deploy,omitempty"`
+	Devices         []string              `json:"devices,omitempty"`
+	Dns             StringOrList          `json:"dns,omitempty"`
+	DnsSearch       StringOrList          `json:"dns_search,omitempty"`
+	Domainname      string                `json:"domainname,omitempty"`
+	Entrypoint      ShellCommand          `json:"entrypoint,omitempty"`
+	EnvFile         EnvFiles              `json:"env_file,omitempty"`
+	Environment     MappingWithEquals     `json:"environment,omitempty"`
+	Expose          []interface{}         `json:"expose,omitempty"`  // string,number
+	Extends         interface{}           `json:"extends,omitempty"` // string,object
+	ExternalLinks   []string              `json:"external_links,omitempty"`
+	ExtraHosts      interface{}           `json:"extra_hosts,omitempty"`
+	Healthcheck     *HealthcheckV37       `json:"healthcheck,omitempty"`
+	Hostname        string                `json:"hostname,omitempty"`
+	Image           string                `json:"image,omitempty"`
+	Init            bool                  `json:"init,omitempty"`
+	Ipc             string                `json:"ipc,omitempty"`
+	Isolation       string                `json:"isolation,omitempty"`
+	Labels          Mapping               `json:"labels,omitempty"`
+	Links           []string              `json:"links,omitempty"`
+	Logging         *LoggingV37           `json:"logging,omitempty"`
+	MacAddress      string                `json:"mac_address,omitempty"`
+	NetworkMode     string                `json:"network_mode,omitempty"`
+	Networks        interface{}           `json:"networks,omitempty"` // object
+	Pid             interface{}           `json:"pid,omitempty"`      // string,null
+	Ports           ServicePorts          `json:"ports,omitempty"`
+	Privileged      bool                  `json:"privileged,omitempty"`
+	ReadOnly        bool                  `json:"read_only,omitempty"`
+	Restart         string                `json:"restart,omitempty"`
+	Secrets         ServiceFileReferences `json:"secrets,omitempty"`
+	SecurityOpt     []string              `json:"security_opt,omitempty"`
+	ShmSize         interface{}           `json:"shm_size,omitempty"` // number,string
+	StdinOpen       bool                  `json:"stdin_open,omitempty"`
+	StopGracePeriod string                `json:"stop_grace_period,omitempty"`
+	StopSignal      string                `json:"stop_signal,omitempty"`
+	Sysctls         Mapping               `json:"sysctls,omitempty"`
+	Tmpfs           StringOrList          `json:"tmpfs,omitempty"`
+	Tty             bool                  `json:"tty,omitempty"`
+	Ulimits         Ulimits               `json:"ulimits,omitempty"`
+	User            string                `json:"user,omitempty"`
+	UsernsMode      string                `json:"userns_mode,omitempty"`
+	Volumes         ServiceVolumes        `json:"volumes,omitempty"`
+	WorkingDir      string                `json:"working_dir,omitempty"`
+	XSloppy         Extension             `json:"x-sloppy,omitempty"`
+}
+
+type UpdateConfigV37 struct {
+	Delay           string  `json:"delay,omitempty"`
+	FailureAction   string  `json:"failure_action,omitempty"`
+	MaxFailureRatio float64 `json:"max_failure_ratio,omitempty"`
+	Monitor         string  `json:"monitor,omitempty"`
+	Order           string  `json:"order,omitempty"`
+	Parallelism     int     `json:"parallelism,omitempty"`
+}
+
+type VolumeV37 struct {
+	Driver     string      `json:"driver,omitempty"`
+	DriverOpts interface{} `json:"driver_opts,omitempty"`
+	External   interface{} `json:"external,omitempty"` // boolean,object
+	Labels     Mapping     `json:"labels,omitempty"`
+	Name       string      `json:"name,omitempty"`
+}
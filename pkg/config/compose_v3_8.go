@@ -0,0 +1,207 @@
+// Code generated by 'github.com/sevenval/structgen'. DO NOT EDIT.
+
+package config
+
+type ConfigV38 struct {
+	External       interface{} `json:"external,omitempty"` // boolean,object
+	File           string      `json:"file,omitempty"`
+	Labels         Mapping     `json:"labels,omitempty"`
+	Name           string      `json:"name,omitempty"`
+	TemplateDriver string      `json:"template_driver,omitempty"`
+}
+
+type CredentialSpecV38 struct {
+	Config   string `json:"config,omitempty"`
+	File     string `json:"file,omitempty"`
+	Registry string `json:"registry,omitempty"`
+}
+
+type DeploymentV38 struct {
+	EndpointMode   string             `json:"endpoint_mode,omitempty"`
+	Labels         Mapping            `json:"labels,omitempty"`
+	Mode           string             `json:"mode,omitempty"`
+	Placement      *PlacementV38      `json:"placement,omitempty"`
+	Replicas       int                `json:"replicas,omitempty"`
+	Resources      *ResourcesV38      `json:"resources,omitempty"`
+	RestartPolicy  *RestartPolicyV38  `json:"restart_policy,omitempty"`
+	RollbackConfig *RollbackConfigV38 `json:"rollback_config,omitempty"`
+	UpdateConfig   *UpdateConfigV38   `json:"update_config,omitempty"`
+}
+
+type DiscreteResourceSpecV38 struct {
+	Kind  string  `json:"kind,omitempty"`
+	Value float64 `json:"value,omitempty"`
+}
+
+type DockerComposeV38 struct {
+	Configs  map[string]*ConfigV38  `json:"configs,omitempty"`
+	Networks map[string]*NetworkV38 `json:"networks,omitempty"`
+	Secrets  map[string]*SecretV38  `json:"secrets,omitempty"`
+	Services map[string]*ServiceV38 `json:"services,omitempty"`
+	Version  string                 `json:"version"`
+	Volumes  map[string]*VolumeV38  `json:"volumes,omitempty"`
+	XSloppy  Extension              `json:"x-sloppy,omitempty"`
+}
+
+type ExternalV38 struct {
+	Name string `json:"name,omitempty"`
+}
+
+type GenericResourcesV38 struct {
+	DiscreteResourceSpec *DiscreteResourceSpecV38 `json:"discrete_resource_spec,omitempty"`
+}
+
+type HealthcheckV38 struct {
+	Disable     bool            `json:"disable,omitempty"`
+	Interval    string          `json:"interval,omitempty"`
+	Retries     float64         `json:"retries,omitempty"`
+	StartPeriod string          `json:"start_period,omitempty"`
+	Test        HealthcheckTest `json:"test,omitempty"`
+	Timeout     string          `json:"timeout,omitempty"`
+}
+
+type IpamV38 struct {
+	Config []*ConfigV38 `json:"config,omitempty"`
+	Driver string       `json:"driver,omitempty"`
+}
+
+type LimitsV38 struct {
+	Cpus   string `json:"cpus,omitempty"`
+	Memory string `json:"memory,omitempty"`
+}
+
+type LoggingV38 struct {
+	Driver  string      `json:"driver,omitempty"`
+	Options interface{} `json:"options,omitempty"`
+}
+
+type NetworkV38 struct {
+	Attachable bool        `json:"attachable,omitempty"`
+	Driver     string      `json:"driver,omitempty"`
+	DriverOpts interface{} `json:"driver_opts,omitempty"`
+	External   interface{} `json:"external,omitempty"` // boolean,object
+	Internal   bool        `json:"internal,omitempty"`
+	Ipam       *IpamV38    `json:"ipam,omitempty"`
+	Labels     Mapping     `json:"labels,omitempty"`
+	Name       string      `json:"name,omitempty"`
+}
+
+type PlacementV38 struct {
+	Constraints        []string          `json:"constraints,omitempty"`
+	MaxReplicasPerNode int               `json:"max_replicas_per_node,omitempty"`
+	Preferences        []*PreferencesV38 `json:"preferences,omitempty"`
+}
+
+type PreferencesV38 struct {
+	Spread string `json:"spread,omitempty"`
+}
+
+type ReservationsV38 struct {
+	Cpus             string                 `json:"cpus,omitempty"`
+	GenericResources []*GenericResourcesV38 `json:"generic_resources,omitempty"`
+	Memory           string                 `json:"memory,omitempty"`
+}
+
+type ResourcesV38 struct {
+	Limits       *LimitsV38       `json:"limits,omitempty"`
+	Reservations *ReservationsV38 `json:"reservations,omitempty"`
+}
+
+type RestartPolicyV38 struct {
+	Condition   string `json:"condition,omitempty"`
+	Delay       string `json:"delay,omitempty"`
+	MaxAttempts int    `json:"max_attempts,omitempty"`
+	Window      string `json:"window,omitempty"`
+}
+
+type RollbackConfigV38 struct {
+	Delay           string  `json:"delay,omitempty"`
+	FailureAction   string  `json:"failure_action,omitempty"`
+	MaxFailureRatio float64 `json:"max_failure_ratio,omitempty"`
+	Monitor         string  `json:"monitor,omitempty"`
+	Order           string  `json:"order,omitempty"`
+	Parallelism     int     `json:"parallelism,omitempty"`
+}
+
+type SecretV38 struct {
+	Driver         string      `json:"driver,omitempty"`
+	DriverOpts     interface{} `json:"driver_opts,omitempty"`
+	External       interface{} `json:"external,omitempty"` // boolean,object
+	File           string      `json:"file,omitempty"`
+	Labels         Mapping     `json:"labels,omitempty"`
+	Name           string      `json:"name,omitempty"`
+	TemplateDriver string      `json:"template_driver,omitempty"`
+}
+
+type ServiceV38 struct {
+	Build           interface{}           `json:"build,omitempty"` // string,object
+	CapAdd          []string              `json:"cap_add,omitempty"`
+	CapDrop         []string              `json:"cap_drop,omitempty"`
+	CgroupParent    string                `json:"cgroup_parent,omitempty"`
+	Command         ShellCommand          `json:"command,omitempty"`
+	Configs         ServiceFileReferences `json:"configs,omitempty"`
+	ContainerName   string                `json:"container_name,omitempty"`
+	CredentialSpec  *CredentialSpecV38    `json:"credential_spec,omitempty"`
+	DependsOn       DependsOn             `json:"depends_on,omitempty"`
+	Deploy          *DeploymentV38        `json:"deploy,omitempty"`
+	Devices         []string              `json:"devices,omitempty"`
+	Dns             StringOrList          `json:"dns,omitempty"`
+	DnsSearch       StringOrList          `json:"dns_search,omitempty"`
+	Domainname      string                `json:"domainname,omitempty"`
+	Entrypoint      ShellCommand          `json:"entrypoint,omitempty"`
+	EnvFile         EnvFiles              `json:"env_file,omitempty"`
+	Environment     MappingWithEquals     `json:"environment,omitempty"`
+	Expose          []interface{}         `json:"expose,omitempty"`  // string,number
+	Extends         interface{}           `json:"extends,omitempty"` // string,object
+	ExternalLinks   []string              `json:"external_links,omitempty"`
+	ExtraHosts      interface{}           `json:"extra_hosts,omitempty"`
+	Healthcheck     *HealthcheckV38       `json:"healthcheck,omitempty"`
+	Hostname        string                `json:"hostname,omitempty"`
+	Image           string                `json:"image,omitempty"`
+	Init            bool                  `json:"init,omitempty"`
+	Ipc             string                `json:"ipc,omitempty"`
+	Isolation       string                `json:"isolation,omitempty"`
+	Labels          Mapping               `json:"labels,omitempty"`
+	Links           []string              `json:"links,omitempty"`
+	Logging         *LoggingV38           `json:"logging,omitempty"`
+	MacAddress      string                `json:"mac_address,omitempty"`
+	NetworkMode     string                `json:"network_mode,omitempty"`
+	Networks        interface{}           `json:"networks,omitempty"` // object
+	Pid             interface{}           `json:"pid,omitempty"`      // string,null
+	Ports           ServicePorts          `json:"ports,omitempty"`
+	Privileged      bool                  `json:"privileged,omitempty"`
+	ReadOnly        bool                  `json:"read_only,omitempty"`
+	Restart         string                `json:"restart,omitempty"`
+	Secrets         ServiceFileReferences `json:"secrets,omitempty"`
+	SecurityOpt     []string              `json:"security_opt,omitempty"`
+	ShmSize         interface{}           `json:"shm_size,omitempty"` // number,string
+	StdinOpen       bool                  `json:"stdin_open,omitempty"`
+	StopGracePeriod string                `json:"stop_grace_period,omitempty"`
+	StopSignal      string                `json:"stop_signal,omitempty"`
+	Sysctls         Mapping               `json:"sysctls,omitempty"`
+	Tmpfs           StringOrList          `json:"tmpfs,omitempty"`
+	Tty             bool                  `json:"tty,omitempty"`
+	Ulimits         Ulimits               `json:"ulimits,omitempty"`
+	User            string                `json:"user,omitempty"`
+	UsernsMode      string                `json:"userns_mode,omitempty"`
+	Volumes         ServiceVolumes        `json:"volumes,omitempty"`
+	WorkingDir      string                `json:"working_dir,omitempty"`
+	XSloppy         Extension             `json:"x-sloppy,omitempty"`
+}
+
+type UpdateConfigV38 struct {
+	Delay           string  `json:"delay,omitempty"`
+	FailureAction   string  `json:"failure_action,omitempty"`
+	MaxFailureRatio float64 `json:"max_failure_ratio,omitempty"`
+	Monitor         string  `json:"monitor,omitempty"`
+	Order           string  `json:"order,omitempty"`
+	Parallelism     int     `json:"parallelism,omitempty"`
+}
+
+type VolumeV38 struct {
+	Driver     string      `json:"driver,omitempty"`
+	DriverOpts interface{} `json:"driver_opts,omitempty"`
+	External   interface{} `json:"external,omitempty"` // boolean,object
+	Labels     Mapping     `json:"labels,omitempty"`
+	Name       string      `json:"name,omitempty"`
+}
@@ -3,35 +3,462 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
 	"io"
 	"io/ioutil"
 	"os"
 	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
 
 	"github.com/sevenval/structgen"
 )
 
+type target struct {
+	typeName    string
+	inFileName  string
+	outFileName string
+	// typeSuffix is appended to all generated types except the root type
+	// to avoid collisions between schemas sharing the same package.
+	typeSuffix string
+	// specServiceProperties are service properties of the compose
+	// specification the generated types keep as well, see
+	// addServiceProperties.
+	specServiceProperties []string
+}
+
+var targets = []target{
+	// DockerComposeV3 follows the newest supported 3.x version, files of
+	// older versions are decoded into their own types first.
+	{
+		typeName:    "DockerComposeV3",
+		inFileName:  "config_schema_v3.9.json",
+		outFileName: "compose_v3.go",
+		// files of the compose specification are decoded into these
+		// types as well
+		specServiceProperties: []string{"profiles"},
+	},
+	{
+		typeName:    "DockerComposeV38",
+		inFileName:  "config_schema_v3.8.json",
+		outFileName: "compose_v3_8.go",
+		typeSuffix:  "V38",
+	},
+	{
+		typeName:    "DockerComposeV37",
+		inFileName:  "config_schema_v3.7.json",
+		outFileName: "compose_v3_7.go",
+		typeSuffix:  "V37",
+	},
+	{
+		typeName:    "DockerComposeV36",
+		inFileName:  "config_schema_v3.6.json",
+		outFileName: "compose_v3_6.go",
+		typeSuffix:  "V36",
+	},
+	{
+		typeName:    "DockerComposeV2",
+		inFileName:  "config_schema_v2.4.json",
+		outFileName: "compose_v2.go",
+		typeSuffix:  "V2",
+	},
+	{
+		typeName:    "DockerComposeSpec",
+		inFileName:  "compose_spec.json",
+		outFileName: "compose_spec.go",
+		typeSuffix:  "Spec",
+	},
+}
+
+const namespace = "config"
+
 func main() {
 	cwd, err := os.Getwd()
 	must(err)
 
 	schemaPath := path.Join(cwd, "pkg/config/schemas")
 
-	typeName := "DockerComposeV3"
-	inFileName := "config_schema_v3.6.json"
-	outFileName := "compose_v3.go"
-	namespace := "config"
+	schemas := make(map[string][]byte)
+	for _, t := range targets {
+		buf, err := ioutil.ReadFile(path.Join(schemaPath, t.inFileName))
+		must(err)
+		src, err := generate(t, buf)
+		must(err)
+		must(ioutil.WriteFile(path.Join(schemaPath, "../", t.outFileName), src, 0644))
+		schemas[t.inFileName] = buf
+	}
 
-	buf, err := ioutil.ReadFile(path.Join(schemaPath, inFileName))
+	src, err := embedSchemas(schemas)
 	must(err)
-	schema, err := structgen.NewSchema(buf)
-	must(err)
-	generator := structgen.NewGenerator(typeName, namespace, schema)
-	f, err := os.OpenFile(path.Join(schemaPath, "../", outFileName), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-	must(err)
-	_, err = io.Copy(f, generator)
-	must(err)
-	must(f.Close())
+	must(ioutil.WriteFile(path.Join(schemaPath, "../", schemasFileName), src, 0644))
+}
+
+const schemasFileName = "schemas.go"
+
+// embedSchemas renders the given schemas as Go source, so they are
+// available at runtime to validate compose files.
+func embedSchemas(schemas map[string][]byte) ([]byte, error) {
+	var names []string
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("// Code generated by pkg/config/schemas/generate.go. DO NOT EDIT.\n\n")
+	buf.WriteString("package " + namespace + "\n\n")
+	buf.WriteString("// Schemas contains the json schemas the types of this package are\n")
+	buf.WriteString("// generated from by their file name.\n")
+	buf.WriteString("var Schemas = map[string]string{\n")
+	for _, name := range names {
+		fmt.Fprintf(buf, "%q: %s,\n", name, quote(schemas[name]))
+	}
+	buf.WriteString("}\n")
+	return format.Source(buf.Bytes())
+}
+
+// Returns a raw string literal if possible to keep the schema readable.
+func quote(schema []byte) string {
+	if bytes.ContainsRune(schema, '`') {
+		return strconv.Quote(string(schema))
+	}
+	return "`" + string(schema) + "`"
+}
+
+// structgen resolves the schema definitions in map order. Sometimes a
+// referenced definition is skipped or a property of the same name takes
+// its place, so generate until the output is complete and stable.
+func generate(t target, schemaBuf []byte) ([]byte, error) {
+	const maxAttempts = 100
+	for i := 0; i < maxAttempts; i++ {
+		schema, err := structgen.NewSchema(schemaBuf)
+		if err != nil {
+			return nil, err
+		}
+		identifyDefinitions(schema)
+		stripExtensions(schema)
+		untypeEmptyObjects(schema)
+		addSloppyExtensions(schema)
+		addServiceProperties(schema, t.specServiceProperties)
+		generator := structgen.NewGenerator(t.typeName, namespace, schema)
+		// the generator renders everything within a single Read call
+		out := make([]byte, 1<<20)
+		n, err := generator.Read(out)
+		if err != io.EOF {
+			return nil, err
+		}
+		src, complete, err := postProcess(out[:n], t, schema)
+		if err != nil || complete {
+			return src, err
+		}
+	}
+	return nil, fmt.Errorf("%s: generated types are incomplete after %d attempts", t.inFileName, maxAttempts)
+}
+
+// identifyDefinitions sets the draft 04 `id` of object definitions without
+// one, structgen only resolves references to definitions by their id.
+// Newer drafts like the one of the compose specification refer to
+// definitions by their path only.
+func identifyDefinitions(schema *structgen.Schema) {
+	for name, s := range schema.Definitions {
+		if s.ID == "" && len(s.Properties) > 0 {
+			s.ID = "#/definitions/" + name
+		}
+	}
+}
+
+// stripExtensions removes the `^x-` pattern of objects with regular
+// properties. Otherwise structgen would treat those objects as maps.
+func stripExtensions(schema *structgen.Schema) {
+	if schema == nil {
+		return
+	}
+	if len(schema.Properties) > 0 {
+		delete(schema.PatternProperties, "^x-")
+	}
+	for _, s := range schema.Properties {
+		stripExtensions(s)
+	}
+	for _, s := range schema.PatternProperties {
+		stripExtensions(s)
+	}
+	for _, s := range schema.Definitions {
+		stripExtensions(s)
+	}
+	for _, list := range [][]*structgen.Schema{schema.OneOf, schema.AnyOf, schema.AllOf} {
+		for _, s := range list {
+			stripExtensions(s)
+		}
+	}
+	stripExtensions(schema.Items)
+}
+
+// untypeEmptyObjects declares objects without any properties, like
+// `storage_opt` of the compose specification, as untyped. Otherwise
+// structgen would refer to a struct it never generates.
+func untypeEmptyObjects(schema *structgen.Schema) {
+	if schema == nil {
+		return
+	}
+	if schema.Type == "object" && len(schema.Properties) == 0 && len(schema.PatternProperties) == 0 {
+		// an untyped `oneOf` becomes `interface{}`, see assignUnionTypes
+		schema.Type = nil
+		schema.OneOf = []*structgen.Schema{{Type: "object"}}
+		return
+	}
+	for _, s := range schema.Properties {
+		untypeEmptyObjects(s)
+	}
+	for _, s := range schema.PatternProperties {
+		untypeEmptyObjects(s)
+	}
+	for _, s := range schema.Definitions {
+		untypeEmptyObjects(s)
+	}
+	for _, list := range [][]*structgen.Schema{schema.OneOf, schema.AnyOf, schema.AllOf} {
+		for _, s := range list {
+			untypeEmptyObjects(s)
+		}
+	}
+	untypeEmptyObjects(schema.Items)
+}
+
+// sloppyExtension is the extension field holding sloppy specific settings.
+const sloppyExtension = "x-sloppy"
+
+// addSloppyExtensions declares the sloppy extension field for the project
+// and its services, so the generated types keep its value. The schemas
+// used for validation are left as they are.
+func addSloppyExtensions(schema *structgen.Schema) {
+	for _, s := range []*structgen.Schema{schema, schema.Definitions["service"]} {
+		if s != nil && s.Properties != nil {
+			// an untyped `oneOf` becomes `interface{}`, see assignUnionTypes
+			s.Properties[sloppyExtension] = &structgen.Schema{
+				OneOf: []*structgen.Schema{{Type: "object"}},
+			}
+		}
+	}
+}
+
+// addServiceProperties declares the given service properties of the
+// compose specification, so the generated types keep their values. Like
+// for addSloppyExtensions the schemas used for validation are left as
+// they are, files of the 3.x versions still can't use them.
+func addServiceProperties(schema *structgen.Schema, properties []string) {
+	service := schema.Definitions["service"]
+	if service == nil || service.Properties == nil {
+		return
+	}
+	for _, name := range properties {
+		// the type is replaced by the union type, see unionTypes
+		service.Properties[name] = &structgen.Schema{
+			OneOf: []*structgen.Schema{{Type: "string"}, {Type: "array"}},
+		}
+	}
+}
+
+// postProcess renames the generated types if required and sorts them by
+// name, so regenerating the same schema always yields the same file.
+// It also reports whether all referenced types have been generated.
+func postProcess(src []byte, t target, schema *structgen.Schema) ([]byte, bool, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, t.outFileName, src, parser.ParseComments)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, ident := range file.Unresolved {
+		if !builtinTypes[ident.Name] {
+			return nil, false, nil
+		}
+	}
+	if !definitionsGenerated(file, schema) {
+		return nil, false, nil
+	}
+
+	assignUnionTypes(file)
+
+	renamed := make(map[string]string)
+	for _, decl := range file.Decls {
+		spec := decl.(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+		if t.typeSuffix != "" && spec.Name.Name != t.typeName {
+			renamed[spec.Name.Name] = spec.Name.Name + t.typeSuffix
+		}
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok {
+			if name, ok := renamed[ident.Name]; ok && ident.Obj != nil && ident.Obj.Kind == ast.Typ {
+				ident.Name = name
+			}
+		}
+		return true
+	})
+
+	header := src[:fset.Position(file.Decls[0].Pos()).Offset]
+	sort.Slice(file.Decls, func(i, j int) bool {
+		return typeName(file.Decls[i]) < typeName(file.Decls[j])
+	})
+
+	buf := &bytes.Buffer{}
+	buf.Write(header)
+	for _, decl := range file.Decls {
+		err = format.Node(buf, fset, &printer.CommentedNode{Node: decl, Comments: file.Comments})
+		if err != nil {
+			return nil, false, err
+		}
+		buf.WriteString("\n\n")
+	}
+	src, err = format.Source(buf.Bytes())
+	return src, true, err
+}
+
+// unionTypes maps the json names of fields accepting several syntaxes to
+// the types of types.go which normalize them. Names prefixed with a struct
+// apply to fields of that struct only.
+var unionTypes = map[string]string{
+	"Service.command":     "ShellCommand",
+	"Service.configs":     "ServiceFileReferences",
+	"Service.depends_on":  "DependsOn",
+	"Service.dns":         "StringOrList",
+	"Service.dns_search":  "StringOrList",
+	"Service.entrypoint":  "ShellCommand",
+	"Service.env_file":    "EnvFiles",
+	"Service.environment": "MappingWithEquals",
+	"Service.ports":       "ServicePorts",
+	"Service.profiles":    "StringOrList",
+	"Service.secrets":     "ServiceFileReferences",
+	"Service.sysctls":     "Mapping",
+	"Service.tmpfs":       "StringOrList",
+	"Service.ulimits":     "Ulimits",
+	"Service.volumes":     "ServiceVolumes",
+	"Healthcheck.test":    "HealthcheckTest",
+	"labels":              "Mapping",
+	sloppyExtension:       "Extension",
+}
+
+// Replaces the `interface{}` types of the fields listed by unionTypes,
+// along with their comment listing the accepted types.
+func assignUnionTypes(file *ast.File) {
+	removed := make(map[*ast.CommentGroup]bool)
+	for _, decl := range file.Decls {
+		spec := decl.(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+		st, ok := spec.Type.(*ast.StructType)
+		if !ok {
+			continue
+		}
+		for _, field := range st.Fields.List {
+			if !isInterface(field.Type) || field.Tag == nil {
+				continue
+			}
+			tag := strings.Trim(field.Tag.Value, "`")
+			jsonName := strings.Split(reflect.StructTag(tag).Get("json"), ",")[0]
+			union, ok := unionTypes[spec.Name.Name+"."+jsonName]
+			if !ok {
+				union, ok = unionTypes[jsonName]
+			}
+			if !ok {
+				continue
+			}
+			field.Type = ast.NewIdent(union)
+			if field.Comment != nil {
+				removed[field.Comment] = true
+				field.Comment = nil
+			}
+		}
+	}
+
+	var comments []*ast.CommentGroup
+	for _, c := range file.Comments {
+		if !removed[c] {
+			comments = append(comments, c)
+		}
+	}
+	file.Comments = comments
+}
+
+// Reports whether the given type is `interface{}` or `[]interface{}`.
+func isInterface(expr ast.Expr) bool {
+	if array, ok := expr.(*ast.ArrayType); ok {
+		expr = array.Elt
+	}
+	_, ok := expr.(*ast.InterfaceType)
+	return ok
+}
+
+// Reports whether the structs of all referenced object definitions
+// have been generated from the definitions themselves.
+func definitionsGenerated(file *ast.File, schema *structgen.Schema) bool {
+	structs := make(map[string]*ast.StructType)
+	referenced := make(map[string]bool)
+	for _, decl := range file.Decls {
+		spec := decl.(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+		if st, ok := spec.Type.(*ast.StructType); ok {
+			structs[spec.Name.Name] = st
+			for _, field := range st.Fields.List {
+				ast.Inspect(field.Type, func(n ast.Node) bool {
+					if ident, ok := n.(*ast.Ident); ok {
+						referenced[ident.Name] = true
+					}
+					return true
+				})
+			}
+		}
+	}
+
+	for name, def := range schema.Definitions {
+		if len(def.Properties) == 0 || !referenced[camelCase(name)] {
+			continue
+		}
+		st, ok := structs[camelCase(name)]
+		if !ok || len(st.Fields.List) != len(def.Properties) {
+			return false
+		}
+		for _, field := range st.Fields.List {
+			tag := strings.Trim(field.Tag.Value, "`")
+			jsonName := strings.Split(reflect.StructTag(tag).Get("json"), ",")[0]
+			if _, ok := def.Properties[jsonName]; !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Same naming as structgen: `list_or_dict` becomes `ListOrDict`.
+func camelCase(in string) string {
+	var out []rune
+	upper := true
+	for _, r := range in {
+		switch {
+		case r == '_' || r == '-':
+			upper = true
+		case upper:
+			out = append(out, unicode.ToUpper(r))
+			upper = false
+		default:
+			out = append(out, r)
+		}
+	}
+	return string(out)
+}
+
+var builtinTypes = map[string]bool{
+	"bool":    true,
+	"float64": true,
+	"int":     true,
+	"string":  true,
+}
+
+func typeName(decl ast.Decl) string {
+	return decl.(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Name.Name
 }
 
 func must(err error) {
@@ -0,0 +1,574 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The types of this file replace the `interface{}` fields generated for
+// schema elements which accept multiple syntaxes. Each of them normalizes
+// all syntaxes to a single Go representation while being unmarshalled and
+// marshals to a syntax it accepts itself. See `unionTypes` of
+// `schemas/generate.go` for the fields they are assigned to.
+
+// StringOrList is a single string or a list of strings, e.g. `dns`.
+type StringOrList []string
+
+func (s *StringOrList) UnmarshalJSON(buf []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+		*s = nil
+	case string:
+		*s = StringOrList{v}
+	case []interface{}:
+		list := make(StringOrList, 0, len(v))
+		for _, e := range v {
+			str, err := scalarString(e)
+			if err != nil {
+				return err
+			}
+			list = append(list, str)
+		}
+		*s = list
+	default:
+		return fmt.Errorf("expected a string or list, got %s", buf)
+	}
+	return nil
+}
+
+// ShellCommand is a command given as string or as list of arguments,
+// e.g. `command` and `entrypoint`. The string is kept as written, it's
+// run by a shell.
+type ShellCommand struct {
+	// Shell is the command of the string syntax.
+	Shell string
+	// Args are the arguments of the list syntax.
+	Args []string
+}
+
+func (c *ShellCommand) UnmarshalJSON(buf []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+		*c = ShellCommand{}
+	case string:
+		*c = ShellCommand{Shell: v}
+	case []interface{}:
+		args := make([]string, 0, len(v))
+		for _, e := range v {
+			str, err := scalarString(e)
+			if err != nil {
+				return err
+			}
+			args = append(args, str)
+		}
+		*c = ShellCommand{Args: args}
+	default:
+		return fmt.Errorf("expected a command string or list, got %s", buf)
+	}
+	return nil
+}
+
+func (c ShellCommand) MarshalJSON() ([]byte, error) {
+	switch {
+	case c.Args != nil:
+		return json.Marshal(c.Args)
+	case c.Shell != "":
+		return json.Marshal(c.Shell)
+	}
+	return []byte("null"), nil
+}
+
+// HealthcheckTest is the `test` of a healthcheck in its list syntax,
+// strings are given as `["CMD-SHELL", "<string>"]`.
+type HealthcheckTest []string
+
+func (t *HealthcheckTest) UnmarshalJSON(buf []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+		*t = nil
+	case string:
+		*t = HealthcheckTest{"CMD-SHELL", v}
+	case []interface{}:
+		test := make(HealthcheckTest, 0, len(v))
+		for _, e := range v {
+			str, err := scalarString(e)
+			if err != nil {
+				return err
+			}
+			test = append(test, str)
+		}
+		*t = test
+	default:
+		return fmt.Errorf("expected a test string or list, got %s", buf)
+	}
+	return nil
+}
+
+// MappingWithEquals is a `list_or_dict` whose keys may lack a value,
+// e.g. `environment`. Those keys map to nil.
+type MappingWithEquals map[string]*string
+
+func (m *MappingWithEquals) UnmarshalJSON(buf []byte) error {
+	entries, err := listOrDict(buf)
+	if err != nil {
+		return err
+	}
+	*m = MappingWithEquals(entries)
+	return nil
+}
+
+// Mapping is a `list_or_dict` whose keys without value map to an
+// empty string, e.g. `labels`.
+type Mapping map[string]string
+
+func (m *Mapping) UnmarshalJSON(buf []byte) error {
+	entries, err := listOrDict(buf)
+	if err != nil {
+		return err
+	}
+	if entries == nil {
+		*m = nil
+		return nil
+	}
+	mapping := make(Mapping, len(entries))
+	for key, val := range entries {
+		if val != nil {
+			mapping[key] = *val
+		} else {
+			mapping[key] = ""
+		}
+	}
+	*m = mapping
+	return nil
+}
+
+// EnvFiles are the `env_file` entries of a service, given as string,
+// list of strings or list of `{path, required}` mappings.
+type EnvFiles []EnvFile
+
+type EnvFile struct {
+	Path     string `json:"path"`
+	Required bool   `json:"required"`
+}
+
+func (f *EnvFiles) UnmarshalJSON(buf []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return err
+	}
+	var entries []interface{}
+	switch v := v.(type) {
+	case nil:
+		*f = nil
+		return nil
+	case string:
+		entries = []interface{}{v}
+	case []interface{}:
+		entries = v
+	default:
+		return fmt.Errorf("expected an env file string or list, got %s", buf)
+	}
+
+	files := make(EnvFiles, 0, len(entries))
+	for _, e := range entries {
+		file := EnvFile{Required: true}
+		switch e := e.(type) {
+		case string:
+			file.Path = e
+		case map[string]interface{}:
+			file.Path, _ = e["path"].(string)
+			if required, ok := e["required"].(bool); ok {
+				file.Required = required
+			}
+		default:
+			return fmt.Errorf("invalid env file %v", e)
+		}
+		files = append(files, file)
+	}
+	*f = files
+	return nil
+}
+
+// Extension is the value of an `x-` extension field, e.g. the sloppy
+// specific settings of `x-sloppy`.
+type Extension map[string]interface{}
+
+// DependsOn are the dependencies of a service by their name, the short
+// list syntax depends on the start of each service.
+type DependsOn map[string]*ServiceDependency
+
+type ServiceDependency struct {
+	Condition string `json:"condition,omitempty"`
+	Restart   bool   `json:"restart,omitempty"`
+	Required  bool   `json:"required"`
+}
+
+// Conditions of the long `depends_on` syntax.
+const (
+	ConditionServiceStarted               = "service_started"
+	ConditionServiceHealthy               = "service_healthy"
+	ConditionServiceCompletedSuccessfully = "service_completed_successfully"
+)
+
+func (d *DependsOn) UnmarshalJSON(buf []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+		*d = nil
+	case []interface{}:
+		deps := make(DependsOn, len(v))
+		for _, e := range v {
+			name, ok := e.(string)
+			if !ok {
+				return fmt.Errorf("invalid dependency %v", e)
+			}
+			deps[name] = &ServiceDependency{Condition: ConditionServiceStarted, Required: true}
+		}
+		*d = deps
+	case map[string]interface{}:
+		deps := make(DependsOn, len(v))
+		for name, e := range v {
+			dep := &ServiceDependency{Condition: ConditionServiceStarted, Required: true}
+			if m, ok := e.(map[string]interface{}); ok {
+				if condition, ok := m["condition"].(string); ok {
+					dep.Condition = condition
+				}
+				dep.Restart, _ = m["restart"].(bool)
+				if required, ok := m["required"].(bool); ok {
+					dep.Required = required
+				}
+			}
+			deps[name] = dep
+		}
+		*d = deps
+	default:
+		return fmt.Errorf("expected a dependency list or mapping, got %s", buf)
+	}
+	return nil
+}
+
+// ServicePorts are the `ports` of a service in their long syntax. Port
+// numbers and ranges of the short syntax are kept as written.
+type ServicePorts []ServicePortConfig
+
+type ServicePortConfig struct {
+	Mode      string `json:"mode,omitempty"`
+	HostIP    string `json:"host_ip,omitempty"`
+	Target    string `json:"target,omitempty"`
+	Published string `json:"published,omitempty"`
+	Protocol  string `json:"protocol,omitempty"`
+}
+
+func (p *ServicePorts) UnmarshalJSON(buf []byte) error {
+	var entries []interface{}
+	if err := json.Unmarshal(buf, &entries); err != nil {
+		return err
+	}
+	if entries == nil {
+		*p = nil
+		return nil
+	}
+
+	ports := make(ServicePorts, 0, len(entries))
+	for _, e := range entries {
+		var port ServicePortConfig
+		switch e := e.(type) {
+		case float64, string:
+			str, _ := scalarString(e)
+			var err error
+			port, err = ParsePortShortSyntax(str)
+			if err != nil {
+				return err
+			}
+		case map[string]interface{}:
+			port.Mode, _ = e["mode"].(string)
+			port.HostIP, _ = e["host_ip"].(string)
+			port.Protocol, _ = e["protocol"].(string)
+			if e["target"] != nil {
+				port.Target, _ = scalarString(e["target"])
+			}
+			if e["published"] != nil {
+				port.Published, _ = scalarString(e["published"])
+			}
+		default:
+			return fmt.Errorf("invalid port %v", e)
+		}
+		ports = append(ports, port)
+	}
+	*p = ports
+	return nil
+}
+
+// ParsePortShortSyntax splits `[HOST_IP:][PUBLISHED:]TARGET[/PROTOCOL]`.
+func ParsePortShortSyntax(in string) (ServicePortConfig, error) {
+	var port ServicePortConfig
+	spec := in
+	if i := strings.LastIndex(spec, "/"); i != -1 {
+		spec, port.Protocol = spec[:i], spec[i+1:]
+	}
+
+	i := strings.LastIndex(spec, ":")
+	port.Target = spec[i+1:]
+	if i != -1 {
+		rest := spec[:i]
+		j := strings.LastIndex(rest, ":")
+		port.Published = rest[j+1:]
+		if j != -1 {
+			port.HostIP = strings.Trim(rest[:j], "[]")
+		}
+	}
+	if port.Target == "" {
+		return port, fmt.Errorf("invalid port %q", in)
+	}
+	return port, nil
+}
+
+// ServiceVolumes are the `volumes` of a service in their long syntax.
+type ServiceVolumes []ServiceVolumeConfig
+
+type ServiceVolumeConfig struct {
+	Type        string               `json:"type,omitempty"`
+	Source      string               `json:"source,omitempty"`
+	Target      string               `json:"target,omitempty"`
+	ReadOnly    bool                 `json:"read_only,omitempty"`
+	Consistency string               `json:"consistency,omitempty"`
+	Bind        *ServiceVolumeBind   `json:"bind,omitempty"`
+	Volume      *ServiceVolumeVolume `json:"volume,omitempty"`
+	Tmpfs       *ServiceVolumeTmpfs  `json:"tmpfs,omitempty"`
+}
+
+type ServiceVolumeBind struct {
+	Propagation string `json:"propagation,omitempty"`
+}
+
+type ServiceVolumeVolume struct {
+	NoCopy bool `json:"nocopy,omitempty"`
+}
+
+type ServiceVolumeTmpfs struct {
+	// Size in bytes or with a unit like `1g`.
+	Size string `json:"size,omitempty"`
+}
+
+const (
+	VolumeTypeBind   = "bind"
+	VolumeTypeVolume = "volume"
+	VolumeTypeTmpfs  = "tmpfs"
+)
+
+func (v *ServiceVolumes) UnmarshalJSON(buf []byte) error {
+	var entries []interface{}
+	if err := json.Unmarshal(buf, &entries); err != nil {
+		return err
+	}
+	if entries == nil {
+		*v = nil
+		return nil
+	}
+
+	volumes := make(ServiceVolumes, 0, len(entries))
+	for _, e := range entries {
+		var volume ServiceVolumeConfig
+		switch e := e.(type) {
+		case string:
+			var err error
+			volume, err = ParseVolumeShortSyntax(e)
+			if err != nil {
+				return err
+			}
+		case map[string]interface{}:
+			// the long syntax matches the struct apart from the tmpfs size
+			if tmpfs, ok := e["tmpfs"].(map[string]interface{}); ok && tmpfs["size"] != nil {
+				tmpfs["size"], _ = scalarString(tmpfs["size"])
+			}
+			b, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			type plain ServiceVolumeConfig
+			if err := json.Unmarshal(b, (*plain)(&volume)); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("invalid volume %v", e)
+		}
+		volumes = append(volumes, volume)
+	}
+	*v = volumes
+	return nil
+}
+
+// ParseVolumeShortSyntax splits `[SOURCE:]TARGET[:MODE]`. Sources which
+// are paths are bind mounts, all others refer to named volumes.
+func ParseVolumeShortSyntax(in string) (ServiceVolumeConfig, error) {
+	volume := ServiceVolumeConfig{Type: VolumeTypeVolume}
+	parts := strings.Split(in, ":")
+	switch len(parts) {
+	case 1:
+		volume.Target = parts[0]
+	case 2, 3:
+		volume.Source, volume.Target = parts[0], parts[1]
+		if len(parts) == 3 {
+			for _, option := range strings.Split(parts[2], ",") {
+				switch option {
+				case "ro":
+					volume.ReadOnly = true
+				case "rw":
+				case "nocopy":
+					volume.Volume = &ServiceVolumeVolume{NoCopy: true}
+				case "consistent", "cached", "delegated":
+					volume.Consistency = option
+				case "shared", "rshared", "slave", "rslave", "private", "rprivate":
+					volume.Bind = &ServiceVolumeBind{Propagation: option}
+				}
+			}
+		}
+	default:
+		return volume, fmt.Errorf("invalid volume %q", in)
+	}
+	if volume.Target == "" {
+		return volume, fmt.Errorf("invalid volume %q", in)
+	}
+	if strings.HasPrefix(volume.Source, "/") || strings.HasPrefix(volume.Source, ".") ||
+		strings.HasPrefix(volume.Source, "~") {
+		volume.Type = VolumeTypeBind
+	}
+	return volume, nil
+}
+
+// ServiceFileReferences are the `secrets` or `configs` of a service in
+// their long syntax.
+type ServiceFileReferences []ServiceFileReference
+
+type ServiceFileReference struct {
+	Source string `json:"source,omitempty"`
+	Target string `json:"target,omitempty"`
+	UID    string `json:"uid,omitempty"`
+	GID    string `json:"gid,omitempty"`
+	Mode   int    `json:"mode,omitempty"`
+}
+
+func (r *ServiceFileReferences) UnmarshalJSON(buf []byte) error {
+	var entries []interface{}
+	if err := json.Unmarshal(buf, &entries); err != nil {
+		return err
+	}
+	if entries == nil {
+		*r = nil
+		return nil
+	}
+
+	refs := make(ServiceFileReferences, 0, len(entries))
+	for _, e := range entries {
+		var ref ServiceFileReference
+		switch e := e.(type) {
+		case string:
+			ref.Source = e
+		case map[string]interface{}:
+			ref.Source, _ = e["source"].(string)
+			ref.Target, _ = e["target"].(string)
+			ref.UID, _ = e["uid"].(string)
+			ref.GID, _ = e["gid"].(string)
+			if mode, ok := e["mode"].(float64); ok {
+				ref.Mode = int(mode)
+			}
+		default:
+			return fmt.Errorf("invalid file reference %v", e)
+		}
+		refs = append(refs, ref)
+	}
+	*r = refs
+	return nil
+}
+
+// Ulimits are the `ulimits` of a service by their name, single values
+// are used as soft and hard limit.
+type Ulimits map[string]*UlimitsConfig
+
+type UlimitsConfig struct {
+	Soft int `json:"soft"`
+	Hard int `json:"hard"`
+}
+
+func (u *UlimitsConfig) UnmarshalJSON(buf []byte) error {
+	var single int
+	if err := json.Unmarshal(buf, &single); err == nil {
+		u.Soft, u.Hard = single, single
+		return nil
+	}
+	type plain UlimitsConfig
+	return json.Unmarshal(buf, (*plain)(u))
+}
+
+// Returns the entries of a `list_or_dict` element, list entries have
+// the format `KEY=value` or `KEY`.
+func listOrDict(buf []byte) (map[string]*string, error) {
+	var v interface{}
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		entries := make(map[string]*string, len(v))
+		for _, e := range v {
+			str, err := scalarString(e)
+			if err != nil {
+				return nil, err
+			}
+			split := strings.SplitN(str, "=", 2)
+			if len(split) == 2 {
+				entries[split[0]] = &split[1]
+			} else {
+				entries[split[0]] = nil
+			}
+		}
+		return entries, nil
+	case map[string]interface{}:
+		entries := make(map[string]*string, len(v))
+		for key, val := range v {
+			if val == nil {
+				entries[key] = nil
+				continue
+			}
+			str, err := scalarString(val)
+			if err != nil {
+				return nil, err
+			}
+			entries[key] = &str
+		}
+		return entries, nil
+	}
+	return nil, fmt.Errorf("expected a list or mapping, got %s", buf)
+}
+
+// Returns the string representation of yaml scalars.
+func scalarString(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", fmt.Errorf("expected a scalar value, got %v", v)
+}
@@ -0,0 +1,240 @@
+package converter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sloppyio/sloppose/pkg/config"
+)
+
+// composeDocument is a single loaded compose file whose services may
+// still extend other services.
+type composeDocument struct {
+	// filename is empty for documents not read from disk.
+	filename string
+	tree     map[string]interface{}
+	file     *config.DockerComposeV3
+	// services with resolved `extends` by name
+	resolved map[string]*config.Service
+	// keys declared by the resolved services including those of the
+	// services they extend
+	keys map[string]map[string]interface{}
+}
+
+// Returns the directory relative files of the document are located in.
+func (doc *composeDocument) dir() (string, error) {
+	if doc.filename != "" {
+		return filepath.Dir(doc.filename), nil
+	}
+	return os.Getwd()
+}
+
+// Services of documents read from disk are prefixed with their file
+// relative to the working directory, e.g. `common.yml:web`.
+func (doc *composeDocument) chainEntry(name string) string {
+	if doc.filename == "" {
+		return name
+	}
+	return displayPath(doc.filename) + ":" + name
+}
+
+func (doc *composeDocument) displayName() string {
+	if doc.filename == "" {
+		return "compose file"
+	}
+	return doc.filename
+}
+
+// Returns the given path relative to the working directory if possible.
+func displayPath(path string) string {
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			return rel
+		}
+	}
+	return path
+}
+
+// Returns the parsed yaml of the given service.
+func (doc *composeDocument) serviceTree(name string) map[string]interface{} {
+	services, _ := doc.tree["services"].(map[string]interface{})
+	tree, _ := services[name].(map[string]interface{})
+	return tree
+}
+
+// Returns the keys declared by the document, those of the services
+// include the keys of the services they extend.
+func (doc *composeDocument) declaredKeys() map[string]interface{} {
+	keys := make(map[string]interface{}, len(doc.tree))
+	for k, v := range doc.tree {
+		keys[k] = v
+	}
+	services := make(map[string]interface{}, len(doc.keys))
+	for name, serviceKeys := range doc.keys {
+		services[name] = serviceKeys
+	}
+	keys["services"] = services
+	return keys
+}
+
+// Returns the `extends` declaration of the given service, the file is
+// empty for services of the same document.
+func (doc *composeDocument) extends(name string) (file, service string, ok bool, err error) {
+	tree := doc.serviceTree(name)
+	switch extends := tree["extends"].(type) {
+	case nil:
+		return "", "", false, nil
+	case string:
+		return "", extends, true, nil
+	case map[string]interface{}:
+		file, _ = extends["file"].(string)
+		service, _ = extends["service"].(string)
+		if service == "" {
+			return "", "", false, fmt.Errorf("service %q: extends requires a service", name)
+		}
+		return file, service, true, nil
+	}
+	return "", "", false, fmt.Errorf("service %q: invalid extends declaration", name)
+}
+
+// Resolves the `extends` declaration of the given service recursively.
+// The chain contains the services extended so far to detect cycles.
+func (cl *ComposeLoader) resolveService(doc *composeDocument, name string, chain []string) (*config.Service, error) {
+	if service, ok := doc.resolved[name]; ok {
+		return service, nil
+	}
+	service, ok := doc.file.Services[name]
+	if !ok {
+		return nil, fmt.Errorf("service %q not found in %s", name, doc.displayName())
+	}
+
+	file, baseName, ok, err := doc.extends(name)
+	if err != nil || !ok {
+		doc.resolved[name] = service
+		doc.keys[name] = cl.serviceKeys(doc.serviceTree(name))
+		return service, err
+	}
+
+	entry := doc.chainEntry(name)
+	chain = append(chain, entry)
+	for _, c := range chain[:len(chain)-1] {
+		if c == entry {
+			return nil, fmt.Errorf("circular extends: %s", strings.Join(chain, " -> "))
+		}
+	}
+
+	baseDoc := doc
+	if file != "" {
+		baseDoc, err = cl.extendedDocument(doc, file)
+		if _, ok := err.(ValidationErrors); ok {
+			return nil, err
+		} else if err != nil {
+			return nil, fmt.Errorf("service %q: %v", name, err)
+		}
+	}
+	if _, ok := baseDoc.file.Services[baseName]; !ok {
+		return nil, fmt.Errorf("service %q extends unknown service %q", name, baseName)
+	}
+	base, err := cl.resolveService(baseDoc, baseName, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	extended := &config.Service{}
+	err = cl.decode(base, extended)
+	if err != nil {
+		return nil, err
+	}
+	// never shared between extending services
+	extended.Links, extended.DependsOn = nil, nil
+
+	if baseDoc != doc {
+		fromDir, err := baseDoc.dir()
+		if err != nil {
+			return nil, err
+		}
+		toDir, err := doc.dir()
+		if err != nil {
+			return nil, err
+		}
+		cl.rebaseService(extended, fromDir, toDir)
+	}
+
+	merger := &ComposeMerger{}
+	serviceKeys := cl.serviceKeys(doc.serviceTree(name))
+	service = merger.MergeService(extended, service, serviceKeys)
+	service.Extends = nil
+	doc.resolved[name] = service
+	keys := mergeKeys(baseDoc.keys[baseName], serviceKeys)
+	delete(keys, "extends")
+	doc.keys[name] = keys
+	return service, nil
+}
+
+// Returns the document of a file referred to by `extends`, relative
+// paths are resolved from the directory of the extending document.
+func (cl *ComposeLoader) extendedDocument(doc *composeDocument, file string) (*composeDocument, error) {
+	if !filepath.IsAbs(file) {
+		dir, err := doc.dir()
+		if err != nil {
+			return nil, err
+		}
+		file = filepath.Join(dir, file)
+	}
+	if baseDoc, ok := cl.documents[file]; ok {
+		return baseDoc, nil
+	}
+
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	baseDoc, err := cl.loadDocument(&ComposeSource{Filename: file, Content: buf})
+	if err != nil {
+		return nil, fileError(file, err)
+	}
+	return baseDoc, nil
+}
+
+// Rewrites the relative paths of a service taken from another file, so
+// they still point to the same location from the given directory.
+func (cl *ComposeLoader) rebaseService(service *config.Service, fromDir, toDir string) {
+	rebase := func(p string) string {
+		if p == "" || filepath.IsAbs(p) || strings.HasPrefix(p, "~") {
+			return p
+		}
+		rel, err := filepath.Rel(toDir, filepath.Join(fromDir, p))
+		if err != nil {
+			return p
+		}
+		return rel
+	}
+
+	for i := range service.EnvFile {
+		service.EnvFile[i].Path = rebase(service.EnvFile[i].Path)
+	}
+
+	switch build := service.Build.(type) {
+	case string:
+		service.Build = rebase(build)
+	case map[string]interface{}:
+		if context, ok := build["context"].(string); ok {
+			build["context"] = rebase(context)
+		}
+	}
+
+	for i := range service.Volumes {
+		volume := &service.Volumes[i]
+		if volume.Type != config.VolumeTypeBind {
+			continue
+		}
+		source := rebase(volume.Source)
+		if strings.HasPrefix(volume.Source, ".") && !strings.HasPrefix(source, ".") && !filepath.IsAbs(source) {
+			source = "./" + source
+		}
+		volume.Source = source
+	}
+}
@@ -0,0 +1,73 @@
+package converter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sloppyio/sloppose/internal/test"
+	"github.com/sloppyio/sloppose/pkg/config"
+	"github.com/sloppyio/sloppose/pkg/converter"
+)
+
+func TestComposeLoader_ExtendsFile(t *testing.T) {
+	helper := test.NewHelper(t)
+	reader := &converter.ComposeReader{}
+	source, err := reader.ReadSource("testdata/extends/docker-compose.yml")
+	helper.Must(err)
+
+	loader := &converter.ComposeLoader{}
+	doc, _, err := loader.Load(source)
+	helper.Must(err)
+
+	web := &config.Service{
+		Image:       "nginx",
+		EnvFile:     config.EnvFiles{{Path: "common/common.env", Required: true}},
+		Environment: config.MappingWithEquals{"DEBUG": ToStrPtr("1")},
+		Volumes: config.ServiceVolumes{
+			{Type: "bind", Source: "./common/html", Target: "/usr/share/nginx/html"},
+		},
+		Ports: config.ServicePorts{{Target: "80"}},
+	}
+	admin := &config.Service{
+		Image:       "nginx:alpine",
+		EnvFile:     config.EnvFiles{{Path: "common/common.env", Required: true}},
+		Environment: config.MappingWithEquals{"DEBUG": ToStrPtr("1")},
+		Volumes: config.ServiceVolumes{
+			{Type: "bind", Source: "./common/html", Target: "/usr/share/nginx/html"},
+		},
+		Ports: config.ServicePorts{{Target: "80"}},
+	}
+	expected := map[string]*config.Service{"web": web, "admin": admin}
+	for name, service := range expected {
+		if diff := cmp.Diff(doc.Services[name], service); diff != "" {
+			t.Errorf("Service %q differs: (-got +want)\n%s", name, diff)
+		}
+	}
+}
+
+func TestComposeLoader_ExtendsCircularFiles(t *testing.T) {
+	helper := test.NewHelper(t)
+	reader := &converter.ComposeReader{}
+	source, err := reader.ReadSource("testdata/extends/circular.yml")
+	helper.Must(err)
+
+	loader := &converter.ComposeLoader{}
+	_, _, err = loader.Load(source)
+	if err == nil || !strings.Contains(err.Error(), "circular extends") {
+		t.Errorf("Expected a circular extends error, got %v", err)
+	}
+}
+
+func TestComposeLoader_ExtendsUnknownService(t *testing.T) {
+	loader := &converter.ComposeLoader{}
+	_, _, err := loader.Load(&converter.ComposeSource{Content: []byte(`version: "3"
+services:
+  a:
+    extends: b
+`)})
+	if err == nil {
+		t.Errorf("Expected an error due to unknown extended service.")
+	}
+}
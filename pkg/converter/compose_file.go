@@ -1,69 +1,115 @@
 package converter
 
 import (
-	"bufio"
-	"bytes"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
-	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
-	"github.com/ghodss/yaml"
-
 	"github.com/sloppyio/sloppose/pkg/config"
 )
 
 const (
 	DefaultProjectName    = "sloppyio"
 	EnvComposeProjectName = "COMPOSE_PROJECT_NAME"
+	EnvComposeProfiles    = "COMPOSE_PROFILES"
+	dotEnvFileName        = ".env"
 )
 
 var (
 	ErrFileRequired   = errors.New("at least one read file is required")
-	ErrMissingVersion = errors.New("missing version declaration in compose file")
 	ErrComposeVersion = errors.New("given compose version is not supported")
 )
 
 type ComposeFile struct {
-	ProjectName    string
-	ServiceConfigs map[string]*config.Service
+	ProjectName string
+	// Project is the normalized form all conversion stages work on.
+	Project *ComposeProject
+
+	// WorkingDir is the directory of the first compose file,
+	// the project level `.env` file is read from there.
+	WorkingDir string
+	// UnsetVariables lists all interpolated variables without a value.
+	UnsetVariables []string
+	// UnresolvedEnvironment lists the environment keys without a value
+	// which couldn't be taken from the host environment either.
+	UnresolvedEnvironment []*UnresolvedVariable
+	// Warnings describe parts of the compose files which were dropped.
+	Warnings []string
 }
 
-type composeVersion struct {
-	Version string `yaml:"version"`
+// ComposeOptions control how compose files are loaded.
+type ComposeOptions struct {
+	// ProjectName overrides the name of the project if set.
+	ProjectName string
+	// Profiles enable the services of these profiles, `COMPOSE_PROFILES`
+	// is used if none are given.
+	Profiles []string
+	// Services selects the services to convert along with the services
+	// they need, all are converted if none are given.
+	Services []string
+	// Exclude drops services after the selection.
+	Exclude []string
+	// ForbidEnvPassthrough rejects environment keys without a value
+	// instead of taking their value from the host environment.
+	ForbidEnvPassthrough bool
 }
 
-func NewComposeFile(buf []byte, projectName string) (cf *ComposeFile, err error) {
-	if len(buf) == 0 {
+// UnresolvedVariable is an environment key of a service without value.
+type UnresolvedVariable struct {
+	Service string
+	Name    string
+}
+
+// NewComposeFile loads a single compose file.
+func NewComposeFile(buf []byte, projectName string) (*ComposeFile, error) {
+	return NewComposeFileWithOptions([]*ComposeSource{{Content: buf}}, &ComposeOptions{ProjectName: projectName})
+}
+
+// NewComposeFileWithOptions loads all given compose files and merges them
+// in order, later files override earlier ones like multiple `-f` flags do
+// for docker-compose. Relative paths like the project `.env` file are
+// resolved from the directory of the first file.
+func NewComposeFileWithOptions(sources []*ComposeSource, options *ComposeOptions) (cf *ComposeFile, err error) {
+	if len(sources) == 0 {
 		return nil, ErrFileRequired
 	}
 
-	composeVersion, err := cf.parseVersion(buf)
+	cf = &ComposeFile{}
+	cf.WorkingDir, err = cf.workingDir(sources[0])
+	if err != nil {
+		return nil, err
+	}
+	environment, err := cf.environment()
 	if err != nil {
 		return nil, err
 	}
 
-	loader := &ComposeLoader{}
-	switch strings.Split(composeVersion, ".")[0] {
-	case "3":
-		cf, err = loader.LoadVersion3(buf)
-	case "2":
-		err = ErrComposeVersion
-	default:
-		err = ErrMissingVersion
+	loader := &ComposeLoader{
+		Interpolator: &Interpolator{Environment: environment},
 	}
-	if err != nil {
-		return
+	merger := &ComposeMerger{}
+	var merged *config.DockerComposeV3
+	for _, source := range sources {
+		doc, keys, err := loader.Load(source)
+		if err != nil {
+			return nil, fileError(source.Filename, err)
+		}
+		merged = merger.Merge(merged, doc, keys)
 	}
+	cf.UnsetVariables = loader.Interpolator.UnsetVariables()
 
-	if projectName != "" {
-		cf.ProjectName = projectName
+	if options.ProjectName != "" {
+		cf.ProjectName = options.ProjectName
 	} else {
 		if cf.ProjectName == "" {
-			if env, ok := os.LookupEnv(EnvComposeProjectName); ok {
+			if env, ok := environment[EnvComposeProjectName]; ok {
 				cf.ProjectName = env
+			} else if loader.projectName != "" {
+				cf.ProjectName = loader.projectName
 			} else {
 				cf.ProjectName, err = cf.newProjectName()
 				if err != nil {
@@ -74,90 +120,182 @@ func NewComposeFile(buf []byte, projectName string) (cf *ComposeFile, err error)
 	}
 	cf.ProjectName = strings.ToLower(cf.ProjectName) // TODO remove _- ?
 
-	err = cf.loadEnvFile()
+	cf.Project, err = NewComposeProject(cf.ProjectName, merged)
+	if err != nil {
+		return nil, err
+	}
+	profiles := options.Profiles
+	if len(profiles) == 0 && environment[EnvComposeProfiles] != "" {
+		profiles = strings.Split(environment[EnvComposeProfiles], ",")
+	}
+	err = cf.Project.ApplyProfiles(profiles)
+	if err != nil {
+		return nil, err
+	}
+	err = cf.loadEnvFiles(environment)
+	if err != nil {
+		return nil, err
+	}
+	warnings, err := cf.Project.SelectServices(options.Services, options.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	cf.Warnings = append(loader.warnings, warnings...)
+	warnings, err = cf.Project.skipServices()
+	if err != nil {
+		return nil, err
+	}
+	cf.Warnings = append(cf.Warnings, warnings...)
+	err = cf.resolveEnvironment(environment, options.ForbidEnvPassthrough)
 	return
 }
 
-// Returns the current working directory name.
+// Returns the name of the project directory, which is the directory
+// of the first compose file.
 func (cf *ComposeFile) newProjectName() (p string, err error) {
-	wd, err := os.Getwd()
+	p, err = filepath.Abs(cf.WorkingDir)
 	if err != nil {
 		return p, err
 	}
-	p, err = filepath.Abs(wd)
 	p = filepath.Base(p)
 
-	if p == "." {
+	if p == "." || p == string(filepath.Separator) {
 		p = DefaultProjectName
 	}
 	return
 }
 
-func (cf *ComposeFile) loadEnvFile() error {
-	cwd, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-	for _, service := range cf.ServiceConfigs {
-		if service.EnvFile == nil {
+// Sets the variables of the `env_file` entries of each service. Later
+// files override earlier ones and the `environment` of the service
+// overrides them all. Relative paths are resolved from the working dir.
+func (cf *ComposeFile) loadEnvFiles(environment map[string]string) error {
+	for _, service := range cf.Project.Services {
+		if len(service.EnvFiles) == 0 {
 			continue
 		}
-		var files []string
-		switch service.EnvFile.(type) { // string or list
-		case string:
-			files = append(files, service.EnvFile.(string))
-		case []interface{}:
-			envs := service.EnvFile.([]interface{})
-			for _, e := range envs {
-				files = append(files, e.(string))
+		vars := make(map[string]*string)
+		for _, envFile := range service.EnvFiles {
+			filename := envFile.Path
+			if !filepath.IsAbs(filename) {
+				filename = filepath.Join(cf.WorkingDir, filename)
 			}
-		}
-
-		var vars []interface{}
-		for i := len(files) - 1; i >= 0; i-- {
-			envFile := path.Join(cwd, files[i])
-			content, err := ioutil.ReadFile(envFile)
+			content, err := ioutil.ReadFile(filename)
 			if err != nil {
-				return err
-			}
-			scanner := bufio.NewScanner(bytes.NewBuffer(content))
-			for scanner.Scan() {
-				line := strings.TrimSpace(scanner.Text())
-
-				if len(line) > 0 && !strings.HasPrefix(line, "#") {
-					key := strings.SplitAfter(line, "=")[0]
-
-					found := false
-					for _, v := range vars {
-						if strings.HasPrefix(v.(string), key) {
-							found = true
-							break
-						}
-					}
-
-					if !found {
-						vars = append(vars, line)
-					}
+				if !envFile.Required && os.IsNotExist(err) {
+					continue
 				}
+				return fmt.Errorf("service %q: %v", service.Name, err)
 			}
-
-			if scanner.Err() != nil {
-				scanner.Err()
+			fileVars, err := parseDotEnv(content, environment)
+			if err != nil {
+				return fmt.Errorf("service %q: env file %s: %v", service.Name, displayPath(filename), err)
+			}
+			for key, val := range fileVars {
+				vars[key] = val
 			}
 		}
+		for key, val := range service.Environment {
+			vars[key] = val
+		}
 		service.Environment = vars
+		service.EnvFiles = nil
+	}
+	return nil
+}
+
+// Takes the values of environment keys without value from the given host
+// environment, like `docker run -e KEY` does. Keys missing there are
+// omitted and reported.
+func (cf *ComposeFile) resolveEnvironment(environment map[string]string, forbidPassthrough bool) error {
+	for _, name := range cf.Project.ServiceNames() {
+		service := cf.Project.Services[name]
+		var keys []string
+		for key, val := range service.Environment {
+			if val == nil {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
 
-		// delete
-		service.EnvFile = nil
+		for _, key := range keys {
+			if forbidPassthrough {
+				return fmt.Errorf("service %q: the environment variable %q has no value and passing it through from the host is forbidden", name, key)
+			}
+			if val, ok := environment[key]; ok {
+				service.Environment[key] = &val
+				continue
+			}
+			cf.UnresolvedEnvironment = append(cf.UnresolvedEnvironment, &UnresolvedVariable{Service: name, Name: key})
+		}
 	}
 	return nil
 }
 
-func (cf *ComposeFile) parseVersion(bytes []byte) (string, error) {
-	var version composeVersion
-	err := yaml.Unmarshal(bytes, &version)
-	if err != nil {
-		return "", err
+// Returns the directory of the given source or the current working
+// directory for sources not read from disk.
+func (cf *ComposeFile) workingDir(source *ComposeSource) (string, error) {
+	if source.Filename != "" {
+		return filepath.Dir(source.Filename), nil
+	}
+	return os.Getwd()
+}
+
+// Returns the variables available for interpolation. Values of the
+// process environment take precedence over the project `.env` file.
+func (cf *ComposeFile) environment() (map[string]string, error) {
+	return readEnvironment([]string{filepath.Join(cf.WorkingDir, dotEnvFileName)}, true)
+}
+
+// Prefixes the given error with the file it occurred in. Validation
+// errors already carry their file.
+func fileError(filename string, err error) error {
+	if _, ok := err.(ValidationErrors); ok || filename == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %v", filename, err)
+}
+
+// Returns the variables of the given env files, values of the process
+// environment take precedence. Missing files are skipped if optional.
+func readEnvironment(files []string, optional bool) (map[string]string, error) {
+	processEnv := make(map[string]string)
+	for _, kv := range os.Environ() {
+		split := strings.SplitN(kv, "=", 2)
+		if len(split) == 2 {
+			processEnv[split[0]] = split[1]
+		}
+	}
+
+	env := make(map[string]string)
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			if optional && os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		vars, err := parseDotEnv(content, mergeEnvironment(env, processEnv))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", displayPath(file), err)
+		}
+		for key, val := range vars {
+			if val != nil {
+				env[key] = *val
+			}
+		}
+	}
+	return mergeEnvironment(env, processEnv), nil
+}
+
+// Returns the variables of both maps, those of override take precedence.
+func mergeEnvironment(base, override map[string]string) map[string]string {
+	env := make(map[string]string, len(base)+len(override))
+	for key, val := range base {
+		env[key] = val
+	}
+	for key, val := range override {
+		env[key] = val
 	}
-	return version.Version, nil
+	return env
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 
 	"github.com/sloppyio/sloppose/internal/test"
+	"github.com/sloppyio/sloppose/pkg/config"
 	"github.com/sloppyio/sloppose/pkg/converter"
 )
 
@@ -24,7 +25,7 @@ func TestNewComposeV3File(t *testing.T) {
 
 	services := []string{"foo"}
 	for _, service := range services {
-		_, found := cf.ServiceConfigs[service]
+		_, found := cf.Project.Services[service]
 		if !found {
 			t.Errorf("Couldn't find service %q", service)
 		}
@@ -43,13 +44,105 @@ func TestNewComposeV3dot0File(t *testing.T) {
 
 	services := []string{"busy_env"}
 	for _, service := range services {
-		_, found := cf.ServiceConfigs[service]
+		_, found := cf.Project.Services[service]
 		if !found {
 			t.Errorf("Couldn't find service %q", service)
 		}
 	}
 }
 
+func TestNewComposeV3dot9File(t *testing.T) {
+	buf := []byte(`version: "3.9"
+services:
+  web:
+    image: nginx
+    init: true
+    deploy:
+      rollback_config:
+        parallelism: 2
+      placement:
+        max_replicas_per_node: 1
+`)
+	loader := &converter.ComposeLoader{}
+	doc, _, err := loader.Load(&converter.ComposeSource{Content: buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &config.Service{
+		Image: "nginx",
+		Init:  true,
+		Deploy: &config.Deployment{
+			RollbackConfig: &config.RollbackConfig{Parallelism: 2},
+			Placement:      &config.Placement{MaxReplicasPerNode: 1},
+		},
+	}
+	if diff := cmp.Diff(doc.Services["web"], expected); diff != "" {
+		t.Errorf("Service differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestNewComposeV2File(t *testing.T) {
+	helper := test.NewHelper(t)
+	r := helper.GetTestFile("docker-compose-v2.yml")
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	helper.Must(err)
+
+	loader := &converter.ComposeLoader{}
+	doc, _, err := loader.Load(&converter.ComposeSource{Content: b})
+	helper.Must(err)
+
+	expected := map[string]*config.Service{
+		"web": {
+			Image:       "busybox",
+			Environment: config.MappingWithEquals{"LOG_LEVEL": ToStrPtr("info"), "DB_HOST": ToStrPtr("db:5432")},
+			Ports:       config.ServicePorts{{Published: "8080", Target: "80"}},
+			DependsOn: config.DependsOn{
+				"cache": {Condition: "service_started", Required: true},
+				"db":    {Condition: "service_healthy", Required: true},
+			},
+			Deploy: &config.Deployment{
+				Replicas: 2,
+				Resources: &config.Resources{
+					Limits: &config.Limits{Memory: "512m"},
+				},
+			},
+		},
+		"worker": {
+			Image:       "busybox",
+			Environment: config.MappingWithEquals{"LOG_LEVEL": ToStrPtr("info"), "DB_HOST": ToStrPtr("db:5432")},
+			Ports:       config.ServicePorts{{Published: "8080", Target: "80"}},
+			Deploy: &config.Deployment{
+				Replicas: 2,
+				Resources: &config.Resources{
+					Limits:       &config.Limits{Memory: "268435456b"},
+					Reservations: &config.Reservations{Memory: "128m"},
+				},
+			},
+		},
+	}
+	for name, service := range expected {
+		if diff := cmp.Diff(doc.Services[name], service); diff != "" {
+			t.Errorf("Service %q differs: (-got +want)\n%s", name, diff)
+		}
+	}
+}
+
+func TestNewComposeV2FileCircularExtends(t *testing.T) {
+	buf := []byte(`version: "2"
+services:
+  a:
+    extends: b
+  b:
+    extends: a
+`)
+	_, err := converter.NewComposeFile(buf, "")
+	if err == nil {
+		t.Errorf("Expected an error due to circular extends.")
+	}
+}
+
 func TestNewComposeVersionFile(t *testing.T) {
 	reader := &converter.ComposeReader{}
 	b, err := reader.Read("/testdata/docker-compose-version.yml")
@@ -57,9 +150,106 @@ func TestNewComposeVersionFile(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	_, err = converter.NewComposeFile(b, "")
-	if err == nil {
-		t.Errorf("Expected an error due to missing version field.")
+	// files without version follow the compose specification
+	cf, err := converter.NewComposeFile(b, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := cf.Project.Services["foo"]; !found {
+		t.Errorf("Couldn't find service %q", "foo")
+	}
+}
+
+func TestNewComposeSpecFile(t *testing.T) {
+	helper := test.NewHelper(t)
+	r := helper.GetTestFile("compose-spec.yml")
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	helper.Must(err)
+
+	cf, err := converter.NewComposeFile(b, "")
+	helper.Must(err)
+
+	if cf.ProjectName != "specproject" {
+		t.Errorf("Expected project name %q, got %q", "specproject", cf.ProjectName)
+	}
+
+	loader := &converter.ComposeLoader{}
+	doc, _, err := loader.Load(&converter.ComposeSource{Content: b})
+	helper.Must(err)
+
+	expected := map[string]*config.Service{
+		"web": {
+			Image:     "nginx",
+			Profiles:  config.StringOrList{"frontend"},
+			Ports:     config.ServicePorts{{Target: "80", Published: "8080"}},
+			DependsOn: config.DependsOn{"api": {Condition: "service_healthy", Restart: true, Required: true}},
+			Deploy: &config.Deployment{
+				Resources: &config.Resources{
+					Limits: &config.Limits{Cpus: "0.5", Memory: "128M"},
+				},
+			},
+		},
+		"api": {
+			Image:       "busybox",
+			Environment: config.MappingWithEquals{"LOG_LEVEL": ToStrPtr("info")},
+			Deploy: &config.Deployment{
+				Resources: &config.Resources{
+					Limits: &config.Limits{Memory: "256m"},
+				},
+			},
+		},
+	}
+	for name, service := range expected {
+		if diff := cmp.Diff(doc.Services[name], service); diff != "" {
+			t.Errorf("Service %q differs: (-got +want)\n%s", name, diff)
+		}
+	}
+}
+
+func TestNewComposeSpecFileDroppedKeys(t *testing.T) {
+	b := []byte(`services:
+  web:
+    image: nginx
+    gpus: all
+    label_file: ./app.labels
+    develop:
+      watch:
+      - path: ./src
+        action: sync
+        target: /src
+    post_start:
+    - command: ./migrate.sh
+    healthcheck:
+      test: ["CMD", "true"]
+      start_interval: 5s
+    deploy:
+      resources:
+        limits:
+          memory: 128M
+    x-custom: value
+models:
+  llm:
+    model: ai/smollm2
+`)
+	cf, err := converter.NewComposeFile(b, "spec")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"models is not supported by sloppose and dropped.",
+		"services.web.develop is not supported by sloppose and dropped.",
+		"services.web.gpus is not supported by sloppose and dropped.",
+		"services.web.healthcheck.start_interval is not supported by sloppose and dropped.",
+		"services.web.label_file is not supported by sloppose and dropped.",
+		"services.web.post_start is not supported by sloppose and dropped.",
+	}
+	if diff := cmp.Diff(cf.Warnings, expected); diff != "" {
+		t.Errorf("Result differs: (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(cf.Project.Services["web"].MemoryLimit, "128M"); diff != "" {
+		t.Errorf("Result differs: (-got +want)\n%s", diff)
 	}
 }
 
@@ -87,3 +277,65 @@ func TestNewComposeFileProjectName(t *testing.T) {
 		t.Errorf("Expected %q as project name, diff:\n%s", projectName, diff)
 	}
 }
+
+func TestNewComposeFileEnvironment(t *testing.T) {
+	os.Setenv("SLOPPOSE_PASSTHROUGH", "from host")
+	defer os.Unsetenv("SLOPPOSE_PASSTHROUGH")
+	buf := []byte(`services:
+  list:
+    image: busybox
+    environment:
+    - URL=http://host/?a=b&c=d
+    - SLOPPOSE_PASSTHROUGH
+    - SLOPPOSE_UNRESOLVED
+  map:
+    image: busybox
+    environment:
+      PORT: 8080
+      RATIO: 0.5
+      ENABLED: true
+      SLOPPOSE_PASSTHROUGH:
+`)
+	cf, err := converter.NewComposeFile(buf, "env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf, err := converter.NewSloppyFile(cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]map[string]string{
+		"list": {
+			"URL":                  "http://host/?a=b&c=d",
+			"SLOPPOSE_PASSTHROUGH": "from host",
+		},
+		"map": {
+			"PORT":                 "8080",
+			"RATIO":                "0.5",
+			"ENABLED":              "true",
+			"SLOPPOSE_PASSTHROUGH": "from host",
+		},
+	}
+	for name, env := range expected {
+		if diff := cmp.Diff(sf.Services["apps"][name].App.EnvVars, env); diff != "" {
+			t.Errorf("Environment of %q differs: (-got +want)\n%s", name, diff)
+		}
+	}
+
+	unresolved := []*converter.UnresolvedVariable{{Service: "list", Name: "SLOPPOSE_UNRESOLVED"}}
+	if diff := cmp.Diff(cf.UnresolvedEnvironment, unresolved); diff != "" {
+		t.Errorf("Unresolved variables differ: (-got +want)\n%s", diff)
+	}
+}
+
+func TestNewComposeFileForbidEnvPassthrough(t *testing.T) {
+	buf := []byte("services:\n  web:\n    image: nginx\n    environment: [HOME]\n")
+	_, err := converter.NewComposeFileWithOptions(
+		[]*converter.ComposeSource{{Content: buf}},
+		&converter.ComposeOptions{ForbidEnvPassthrough: true},
+	)
+	if err == nil || !strings.Contains(err.Error(), `"HOME" has no value`) {
+		t.Errorf("Expected an error due to the forbidden passthrough, got %v", err)
+	}
+}
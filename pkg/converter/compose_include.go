@@ -0,0 +1,245 @@
+package converter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/sloppyio/sloppose/pkg/config"
+)
+
+// composeInclude is a single entry of the top-level `include` element.
+// Its paths are already resolved from the including file's directory.
+type composeInclude struct {
+	paths            []string
+	envFiles         []string
+	projectDirectory string
+}
+
+// Loads the projects listed by the `include` element of the given
+// document and adds their resources and declared keys. Resources defined
+// more than once are reported as conflicts.
+func (cl *ComposeLoader) resolveIncludes(doc *composeDocument, keys map[string]interface{}) error {
+	includes, err := cl.includes(doc)
+	if err != nil {
+		return err
+	}
+	dir, err := doc.dir()
+	if err != nil {
+		return err
+	}
+
+	including := cl.including
+	if len(including) == 0 && doc.filename != "" {
+		including = []string{doc.filename}
+	}
+
+	var conflicts []string
+	for _, include := range includes {
+		included, includedKeys, err := cl.loadInclude(include, including)
+		if err != nil {
+			return err
+		}
+		importKeys(keys, includedKeys)
+
+		for _, service := range included.Services {
+			cl.rebaseService(service, include.projectDirectory, dir)
+		}
+
+		var paths []string
+		for _, path := range include.paths {
+			paths = append(paths, displayPath(path))
+		}
+		from := strings.Join(paths, ", ")
+		if doc.file.Services == nil {
+			doc.file.Services = make(map[string]*config.Service)
+		}
+		conflicts = append(conflicts, importResources("service", doc.file.Services, included.Services, from)...)
+		if doc.file.Networks == nil {
+			doc.file.Networks = make(map[string]*config.Network)
+		}
+		conflicts = append(conflicts, importResources("network", doc.file.Networks, included.Networks, from)...)
+		if doc.file.Volumes == nil {
+			doc.file.Volumes = make(map[string]*config.Volume)
+		}
+		conflicts = append(conflicts, importResources("volume", doc.file.Volumes, included.Volumes, from)...)
+		if doc.file.Secrets == nil {
+			doc.file.Secrets = make(map[string]*config.Secret)
+		}
+		conflicts = append(conflicts, importResources("secret", doc.file.Secrets, included.Secrets, from)...)
+		if doc.file.Configs == nil {
+			doc.file.Configs = make(map[string]*config.Config)
+		}
+		conflicts = append(conflicts, importResources("config", doc.file.Configs, included.Configs, from)...)
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("include conflicts: %s", strings.Join(conflicts, "; "))
+	}
+	return nil
+}
+
+// Loads the files of a single include as a project of its own: variables
+// are read from its own env files and its `include` and `extends`
+// declarations are resolved from its own location. The including files
+// are given to detect circular includes.
+func (cl *ComposeLoader) loadInclude(include *composeInclude, including []string) (*config.DockerComposeV3, map[string]interface{}, error) {
+	for _, path := range include.paths {
+		for _, file := range including {
+			if path == file {
+				var chain []string
+				for _, p := range append(append([]string{}, including...), path) {
+					chain = append(chain, displayPath(p))
+				}
+				return nil, nil, fmt.Errorf("circular include: %s", strings.Join(chain, " -> "))
+			}
+		}
+	}
+
+	loader := &ComposeLoader{}
+	if cl.Interpolator != nil {
+		files := include.envFiles
+		optional := false
+		if len(files) == 0 {
+			files = []string{filepath.Join(include.projectDirectory, dotEnvFileName)}
+			optional = true
+		}
+		environment, err := readEnvironment(files, optional)
+		if err != nil {
+			return nil, nil, err
+		}
+		loader.Interpolator = cl.Interpolator.child(environment)
+	}
+
+	merger := &ComposeMerger{}
+	var merged *config.DockerComposeV3
+	var mergedKeys map[string]interface{}
+	for _, path := range include.paths {
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		loader.including = append(append([]string{}, including...), path)
+		doc, keys, err := loader.Load(&ComposeSource{Filename: path, Content: buf})
+		if err != nil {
+			return nil, nil, fileError(displayPath(path), err)
+		}
+		merged = merger.Merge(merged, doc, keys)
+		mergedKeys = mergeKeys(mergedKeys, keys)
+	}
+	cl.warnings = append(cl.warnings, loader.warnings...)
+	return merged, mergedKeys, nil
+}
+
+// Returns the entries of the `include` element. Each entry is either
+// a path or a mapping with `path`, `env_file` and `project_directory`.
+func (cl *ComposeLoader) includes(doc *composeDocument) ([]*composeInclude, error) {
+	entries, ok := doc.tree["include"].([]interface{})
+	if !ok {
+		if doc.tree["include"] != nil {
+			return nil, fmt.Errorf("include has to be a list")
+		}
+		return nil, nil
+	}
+	dir, err := doc.dir()
+	if err != nil {
+		return nil, err
+	}
+	resolve := func(p string) string {
+		if filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+
+	var includes []*composeInclude
+	for i, entry := range entries {
+		include := &composeInclude{}
+		switch e := entry.(type) {
+		case string:
+			include.paths = []string{resolve(e)}
+		case map[string]interface{}:
+			for _, p := range stringOrList(e["path"]) {
+				include.paths = append(include.paths, resolve(p))
+			}
+			for _, p := range stringOrList(e["env_file"]) {
+				include.envFiles = append(include.envFiles, resolve(p))
+			}
+			if projectDirectory, ok := e["project_directory"].(string); ok && projectDirectory != "" {
+				include.projectDirectory = resolve(projectDirectory)
+			}
+		}
+		if len(include.paths) == 0 {
+			return nil, fmt.Errorf("include[%d]: a path is required", i)
+		}
+		if include.projectDirectory == "" {
+			include.projectDirectory = filepath.Dir(include.paths[0])
+		}
+		includes = append(includes, include)
+	}
+	return includes, nil
+}
+
+// Adds the keys of the included resources to those of the including
+// document, the resource mappings of the document are copied beforehand.
+func importKeys(keys, included map[string]interface{}) {
+	for _, kind := range []string{"services", "networks", "volumes", "secrets", "configs"} {
+		src, _ := included[kind].(map[string]interface{})
+		if len(src) == 0 {
+			continue
+		}
+		existing, _ := keys[kind].(map[string]interface{})
+		resources := make(map[string]interface{}, len(existing)+len(src))
+		for name, v := range existing {
+			resources[name] = v
+		}
+		for name, v := range src {
+			if _, ok := resources[name]; !ok {
+				resources[name] = v
+			}
+		}
+		keys[kind] = resources
+	}
+}
+
+// Adds the resources of src to dst, which both have to be maps of the
+// same type. Returns a description of each resource defined in both.
+func importResources(kind string, dst, src interface{}, from string) []string {
+	d, s := reflect.ValueOf(dst), reflect.ValueOf(src)
+	var names []string
+	for _, key := range s.MapKeys() {
+		names = append(names, key.String())
+	}
+	sort.Strings(names)
+
+	var conflicts []string
+	for _, name := range names {
+		key := reflect.ValueOf(name)
+		if d.MapIndex(key).IsValid() {
+			conflicts = append(conflicts, fmt.Sprintf("%s %q of %s is already defined", kind, name, from))
+			continue
+		}
+		d.SetMapIndex(key, s.MapIndex(key))
+	}
+	return conflicts
+}
+
+// Returns the values of a `string_or_list` element.
+func stringOrList(in interface{}) []string {
+	switch v := in.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var out []string
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
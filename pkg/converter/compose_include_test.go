@@ -0,0 +1,59 @@
+package converter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sloppyio/sloppose/internal/test"
+	"github.com/sloppyio/sloppose/pkg/config"
+	"github.com/sloppyio/sloppose/pkg/converter"
+)
+
+func TestComposeLoader_Include(t *testing.T) {
+	helper := test.NewHelper(t)
+	reader := &converter.ComposeReader{}
+	source, err := reader.ReadSource("testdata/include/compose.yml")
+	helper.Must(err)
+
+	loader := &converter.ComposeLoader{Interpolator: &converter.Interpolator{}}
+	doc, _, err := loader.Load(source)
+	helper.Must(err)
+
+	expected := map[string]*config.Service{
+		"web": {
+			Image: "nginx",
+			DependsOn: config.DependsOn{
+				"db":    {Condition: "service_started", Required: true},
+				"cache": {Condition: "service_started", Required: true},
+			},
+		},
+		"db": {
+			Image:   "postgres:10",
+			EnvFile: config.EnvFiles{{Path: "backend/db.env", Required: true}},
+		},
+		"cache": {
+			Image: "redis:4",
+		},
+	}
+	if diff := cmp.Diff(doc.Services, expected); diff != "" {
+		t.Errorf("Services differ: (-got +want)\n%s", diff)
+	}
+	if _, ok := doc.Volumes["data"]; !ok {
+		t.Errorf("Expected the included volume %q", "data")
+	}
+}
+
+func TestComposeLoader_IncludeConflict(t *testing.T) {
+	helper := test.NewHelper(t)
+	reader := &converter.ComposeReader{}
+	source, err := reader.ReadSource("testdata/include/conflict.yml")
+	helper.Must(err)
+
+	loader := &converter.ComposeLoader{}
+	_, _, err = loader.Load(source)
+	if err == nil || !strings.Contains(err.Error(), `service "db"`) {
+		t.Errorf("Expected a conflict of service %q, got %v", "db", err)
+	}
+}
@@ -1,20 +1,527 @@
 package converter
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
 	"github.com/ghodss/yaml"
 
 	"github.com/sloppyio/sloppose/pkg/config"
 )
 
-type ComposeLoader struct{}
+var (
+	ErrInvalidComposeFile = errors.New("compose file has to be a yaml mapping")
+)
+
+type ComposeLoader struct {
+	// Interpolator substitutes variables within the loaded files,
+	// interpolation is skipped if not set.
+	Interpolator *Interpolator
+
+	// project name declared by the last loaded file with a `name`
+	projectName string
+	// documents loaded so far by their filename, files referred
+	// to by `extends` are only loaded once.
+	documents map[string]*composeDocument
+	// files including the loaded one, to detect circular includes
+	including []string
+	// validators by schema file name
+	validators map[string]*SchemaValidator
+	// warnings about dropped parts of the loaded files
+	warnings []string
+}
+
+// Load parses a single compose file, substitutes its variables and
+// decodes it according to its declared version. Files without a version
+// follow the compose specification. The `extends` declarations of all
+// services and the top-level `include` element are resolved, referred
+// files are looked up relative to the directory of the given source.
+// The returned keys are those declared by the file and the files it
+// refers to, they're required to merge it with ComposeMerger.
+func (cl *ComposeLoader) Load(source *ComposeSource) (*config.DockerComposeV3, map[string]interface{}, error) {
+	doc, err := cl.loadDocument(source)
+	if err != nil {
+		return nil, nil, err
+	}
+	if name, ok := doc.tree["name"].(string); ok && name != "" {
+		cl.projectName = name
+	}
+
+	for name := range doc.file.Services {
+		doc.file.Services[name], err = cl.resolveService(doc, name, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	keys := doc.declaredKeys()
+	err = cl.resolveIncludes(doc, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc.file, keys, nil
+}
+
+// Parses, interpolates and decodes the given source without resolving
+// `extends`.
+func (cl *ComposeLoader) loadDocument(source *ComposeSource) (*composeDocument, error) {
+	if len(source.Content) == 0 {
+		return nil, ErrFileRequired
+	}
+
+	tree, err := cl.parse(source.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	if cl.Interpolator != nil {
+		raw := tree
+		tree, err = cl.Interpolator.InterpolateTree(tree)
+		if err != nil {
+			return nil, err
+		}
+		err = cl.convertInterpolated(tree, raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = cl.validate(source, tree)
+	if err != nil {
+		return nil, err
+	}
+
+	composeFile, err := cl.decodeVersion(tree)
+	if err != nil {
+		return nil, err
+	}
+	doc := &composeDocument{
+		filename: source.Filename,
+		tree:     tree,
+		file:     composeFile,
+		resolved: make(map[string]*config.Service),
+		keys:     make(map[string]map[string]interface{}),
+	}
+	if cl.documents == nil {
+		cl.documents = make(map[string]*composeDocument)
+	}
+	if source.Filename != "" {
+		cl.documents[source.Filename] = doc
+	}
+	return doc, nil
+}
+
+// Converts interpolated values to the types the schema of the declared
+// version expects, e.g. `replicas: ${REPLICAS}` to an integer.
+func (cl *ComposeLoader) convertInterpolated(tree, raw map[string]interface{}) error {
+	schema := schemaFileName(cl.version(tree))
+	if schema == "" {
+		return nil
+	}
+	validator, err := cl.validator(schema)
+	if err != nil {
+		return err
+	}
+	validator.Convert(tree, raw)
+	return nil
+}
+
+// Validates the tree against the schema of its declared version. Unquoted
+// version numbers are replaced by their string representation beforehand.
+// Keys only known to newer versions or to the compose specification are
+// reported as such.
+func (cl *ComposeLoader) validate(source *ComposeSource, tree map[string]interface{}) error {
+	version := cl.version(tree)
+	if _, ok := tree["version"]; ok {
+		tree["version"] = version
+	}
+
+	schema := schemaFileName(version)
+	if schema == "" {
+		return nil
+	}
+	var filename string
+	if source.Filename != "" {
+		filename = displayPath(source.Filename)
+	}
+	validator, err := cl.validator(schema)
+	if err != nil {
+		return err
+	}
+	err = validator.Validate(filename, source.Content, tree)
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		return err
+	}
+
+	remaining := errs
+	newer := newerVersions(version)
+	if strings.HasPrefix(schema, "config_schema_v3.") {
+		// keys like `profiles` are only known to the specification
+		newer = append(newer, "")
+	}
+	for _, v := range newer {
+		validator, err := cl.validator(schemaFileName(v))
+		if err != nil {
+			return err
+		}
+		newerErrs, _ := validator.Validate(filename, source.Content, tree).(ValidationErrors)
+		var unresolved ValidationErrors
+		for _, e := range remaining {
+			switch {
+			case containsError(newerErrs, e),
+				// the specification accepts strings for all values
+				v == "" && e.Message != unsupportedMessage:
+				unresolved = append(unresolved, e)
+			case v == "":
+				e.Message = fmt.Sprintf("requires the compose specification, remove the version %s of the file", version)
+			default:
+				e.Message = fmt.Sprintf("requires version %s or later, the file declares %s", v, version)
+			}
+		}
+		remaining = unresolved
+	}
+	return errs
+}
+
+func (cl *ComposeLoader) validator(schema string) (*SchemaValidator, error) {
+	if validator, ok := cl.validators[schema]; ok {
+		return validator, nil
+	}
+	validator, err := NewSchemaValidator(config.Schemas[schema])
+	if err != nil {
+		return nil, err
+	}
+	if cl.validators == nil {
+		cl.validators = make(map[string]*SchemaValidator)
+	}
+	cl.validators[schema] = validator
+	return validator, nil
+}
+
+func containsError(errs ValidationErrors, err *ValidationError) bool {
+	for _, e := range errs {
+		if e.Path == err.Path && e.Message == err.Message {
+			return true
+		}
+	}
+	return false
+}
+
+// 3.x versions with a schema of their own, versions up to 3.6 share one.
+var schemaVersions = []string{"3.6", "3.7", "3.8", "3.9"}
+
+// Returns the schema compose files of the given version are validated
+// against, versions without a known schema are not validated.
+func schemaFileName(version string) string {
+	switch {
+	case version == "":
+		return "compose_spec.json"
+	case version == "2" || strings.HasPrefix(version, "2."):
+		return "config_schema_v2.4.json"
+	case version == "3":
+		return "config_schema_v3.6.json"
+	case strings.HasPrefix(version, "3.") && len(version) == 3:
+		if version[2] <= '6' {
+			return "config_schema_v3.6.json"
+		}
+		for _, v := range schemaVersions {
+			if v == version {
+				return "config_schema_v" + v + ".json"
+			}
+		}
+	}
+	return ""
+}
+
+// Returns the 3.x versions with a schema newer than the given version.
+func newerVersions(version string) []string {
+	schema := schemaFileName(version)
+	if !strings.HasPrefix(schema, "config_schema_v3.") {
+		return nil
+	}
+	var newer []string
+	for _, v := range schemaVersions {
+		if "config_schema_v"+v+".json" > schema {
+			newer = append(newer, v)
+		}
+	}
+	return newer
+}
+
+func (cl *ComposeLoader) decodeVersion(tree map[string]interface{}) (*config.DockerComposeV3, error) {
+	switch version := cl.version(tree); strings.Split(version, ".")[0] {
+	case "3":
+		return cl.LoadVersion3(tree)
+	case "2":
+		return cl.LoadVersion2(tree)
+	case "":
+		return cl.LoadSpec(tree)
+	default:
+		return nil, fmt.Errorf("%v: %q", ErrComposeVersion, version)
+	}
+}
+
+// LoadVersion3 decodes a version 3.x compose file. Files of versions
+// older than 3.9 are decoded by the types of their version first, so keys
+// which are too new for the declared version are dropped.
+func (cl *ComposeLoader) LoadVersion3(tree map[string]interface{}) (*config.DockerComposeV3, error) {
+	var versioned interface{}
+	switch schemaFileName(cl.version(tree)) {
+	case "config_schema_v3.6.json":
+		versioned = &config.DockerComposeV36{}
+	case "config_schema_v3.7.json":
+		versioned = &config.DockerComposeV37{}
+	case "config_schema_v3.8.json":
+		versioned = &config.DockerComposeV38{}
+	}
+	if versioned != nil {
+		err := cl.decode(tree, versioned)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		versioned = tree
+	}
 
-func (cl *ComposeLoader) LoadVersion3(buf []byte) (*ComposeFile, error) {
 	composeFile := &config.DockerComposeV3{}
-	err := yaml.Unmarshal(buf, composeFile)
+	err := cl.decode(versioned, composeFile)
 	if err != nil {
 		return nil, err
 	}
-	return &ComposeFile{
-		ServiceConfigs: composeFile.Services,
-	}, nil
+	return composeFile, nil
+}
+
+// LoadVersion2 decodes a version 2.x compose file and maps it onto the
+// version 3 structure, so both are converted the same way afterwards.
+func (cl *ComposeLoader) LoadVersion2(tree map[string]interface{}) (*config.DockerComposeV3, error) {
+	composeFileV2 := &config.DockerComposeV2{}
+	err := cl.decode(tree, composeFileV2)
+	if err != nil {
+		return nil, err
+	}
+
+	// keys known to both versions share the same name and format
+	composeFile := &config.DockerComposeV3{}
+	err = cl.decode(composeFileV2, composeFile)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cl.applyServiceExtras(composeFileV2.Services, composeFile)
+	if err != nil {
+		return nil, err
+	}
+	return composeFile, nil
+}
+
+// LoadSpec decodes a compose file without version, which follows the
+// compose specification, and maps it onto the version 3 structure. Keys
+// the version 3 structure can't hold are dropped with a warning.
+func (cl *ComposeLoader) LoadSpec(tree map[string]interface{}) (*config.DockerComposeV3, error) {
+	composeFileSpec := &config.DockerComposeSpec{}
+	err := cl.decode(tree, composeFileSpec)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range droppedKeys(reflect.TypeOf(config.DockerComposeV3{}), tree, "") {
+		cl.warnings = append(cl.warnings, fmt.Sprintf("%s is not supported by sloppose and dropped.", path))
+	}
+	for _, service := range composeFileSpec.Services {
+		cl.normalizeSpecService(service)
+	}
+
+	composeFile := &config.DockerComposeV3{}
+	err = cl.decode(composeFileSpec, composeFile)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cl.applyServiceExtras(composeFileSpec.Services, composeFile)
+	if err != nil {
+		return nil, err
+	}
+	return composeFile, nil
+}
+
+// Keys of the compose file handled by the loader itself.
+var loaderKeys = map[string]bool{
+	"name":    true,
+	"include": true,
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// Returns the paths of the keys of val which the given type has no field
+// for, sorted by path. Extensions like `x-foo` are left out, as well as
+// the keys of serviceExtras and those of types decoding themselves.
+func droppedKeys(t reflect.Type, val interface{}, path string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	var dropped []string
+	switch t.Kind() {
+	case reflect.Struct:
+		m, _ := val.(map[string]interface{})
+		extras := reflect.TypeOf(serviceExtras{})
+		for key, v := range m {
+			if strings.HasPrefix(key, "x-") || path == "" && loaderKeys[key] {
+				continue
+			}
+			field, ok := fieldByJSONName(t, key)
+			if !ok {
+				if _, isExtra := fieldByJSONName(extras, key); !isExtra || t != reflect.TypeOf(config.Service{}) {
+					dropped = append(dropped, joinPath(path, key))
+				}
+				continue
+			}
+			dropped = append(dropped, droppedKeys(field.Type, v, joinPath(path, key))...)
+		}
+	case reflect.Map:
+		m, _ := val.(map[string]interface{})
+		for key, v := range m {
+			dropped = append(dropped, droppedKeys(t.Elem(), v, joinPath(path, key))...)
+		}
+	case reflect.Slice:
+		items, _ := val.([]interface{})
+		for i, v := range items {
+			dropped = append(dropped, droppedKeys(t.Elem(), v, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	sort.Strings(dropped)
+	return dropped
+}
+
+// Converts the values the specification allows in other formats than
+// version 3 does.
+func (cl *ComposeLoader) normalizeSpecService(service *config.ServiceSpec) {
+	if service.Deploy != nil && service.Deploy.Resources != nil {
+		resources := service.Deploy.Resources
+		if resources.Limits != nil && resources.Limits.Cpus != nil {
+			resources.Limits.Cpus = fmt.Sprint(resources.Limits.Cpus)
+		}
+		if resources.Reservations != nil && resources.Reservations.Cpus != nil {
+			resources.Reservations.Cpus = fmt.Sprint(resources.Reservations.Cpus)
+		}
+	}
+}
+
+// serviceExtras holds keys of version 2 and the compose specification
+// without a direct version 3 equivalent.
+type serviceExtras struct {
+	MemLimit       interface{} `json:"mem_limit,omitempty"`
+	MemReservation interface{} `json:"mem_reservation,omitempty"`
+	Scale          int         `json:"scale,omitempty"`
+}
+
+// Maps the keys of serviceExtras onto their version 3 equivalents.
+func (cl *ComposeLoader) applyServiceExtras(services interface{}, composeFile *config.DockerComposeV3) error {
+	var extras map[string]*serviceExtras
+	err := cl.decode(services, &extras)
+	if err != nil {
+		return err
+	}
+
+	for name, extra := range extras {
+		cl.convertServiceExtras(extra, composeFile.Services[name])
+	}
+	return nil
+}
+
+func (cl *ComposeLoader) convertServiceExtras(in *serviceExtras, out *config.Service) {
+	if in.MemLimit != nil || in.MemReservation != nil {
+		if out.Deploy == nil {
+			out.Deploy = &config.Deployment{}
+		}
+		if out.Deploy.Resources == nil {
+			out.Deploy.Resources = &config.Resources{}
+		}
+		if in.MemLimit != nil {
+			out.Deploy.Resources.Limits = &config.Limits{Memory: cl.byteSize(in.MemLimit)}
+		}
+		if in.MemReservation != nil {
+			out.Deploy.Resources.Reservations = &config.Reservations{Memory: cl.byteSize(in.MemReservation)}
+		}
+	}
+
+	if in.Scale > 0 {
+		if out.Deploy == nil {
+			out.Deploy = &config.Deployment{}
+		}
+		out.Deploy.Replicas = in.Scale
+	}
+}
+
+// Returns the keys of the given service with those of serviceExtras
+// replaced by their version 3 equivalents, as convertServiceExtras does.
+func (cl *ComposeLoader) serviceKeys(tree map[string]interface{}) map[string]interface{} {
+	resources := make(map[string]interface{})
+	if v, ok := tree["mem_limit"]; ok {
+		resources["limits"] = map[string]interface{}{"memory": v}
+	}
+	if v, ok := tree["mem_reservation"]; ok {
+		resources["reservations"] = map[string]interface{}{"memory": v}
+	}
+	deploy := make(map[string]interface{})
+	if len(resources) > 0 {
+		deploy["resources"] = resources
+	}
+	if v, ok := tree["scale"]; ok {
+		deploy["replicas"] = v
+	}
+	if len(deploy) == 0 {
+		return tree
+	}
+	return mergeKeys(tree, map[string]interface{}{"deploy": deploy})
+}
+
+// Returns memory values given as plain byte numbers with a unit suffix.
+func (cl *ComposeLoader) byteSize(size interface{}) string {
+	if bytes, ok := size.(float64); ok {
+		return strconv.FormatFloat(bytes, 'f', -1, 64) + "b"
+	}
+	return fmt.Sprint(size)
+}
+
+// Parses the yaml document into its generic json representation.
+func (cl *ComposeLoader) parse(buf []byte) (map[string]interface{}, error) {
+	jsonBuf, err := yaml.YAMLToJSON(buf)
+	if err != nil {
+		return nil, err
+	}
+	var tree map[string]interface{}
+	err = json.Unmarshal(jsonBuf, &tree)
+	if err != nil {
+		return nil, ErrInvalidComposeFile
+	}
+	return tree, nil
+}
+
+// Decodes the given value into v by its json representation.
+func (cl *ComposeLoader) decode(in interface{}, v interface{}) error {
+	buf, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, v)
+}
+
+// Returns the declared version, unquoted numbers are accepted as well.
+func (cl *ComposeLoader) version(tree map[string]interface{}) string {
+	switch v := tree["version"].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
 }
@@ -0,0 +1,161 @@
+package converter
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/sloppyio/sloppose/pkg/config"
+)
+
+// ComposeMerger combines multiple compose documents the same way
+// docker-compose applies override files (`-f a.yml -f b.yml`).
+//
+// Scalars of later documents replace earlier ones, `ports` and `expose`
+// are concatenated, `environment` and `labels` are merged by key and
+// `volumes` are merged by their container target. Only the keys declared
+// by the later document are applied, so it may set values to false, 0
+// or empty.
+type ComposeMerger struct{}
+
+// Merge applies override on top of base, keys is the tree of the keys
+// declared by the override like its parsed yaml. The base document is
+// modified in place and returned.
+func (m *ComposeMerger) Merge(base, override *config.DockerComposeV3, keys map[string]interface{}) *config.DockerComposeV3 {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+	m.mergeStruct(reflect.ValueOf(base).Elem(), reflect.ValueOf(override).Elem(), keys)
+	return base
+}
+
+// MergeService applies the override service on top of base, keys is the
+// tree of the keys declared by the override. The base service is modified
+// in place and returned.
+func (m *ComposeMerger) MergeService(base, override *config.Service, keys map[string]interface{}) *config.Service {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+	m.mergeStruct(reflect.ValueOf(base).Elem(), reflect.ValueOf(override).Elem(), keys)
+	return base
+}
+
+func (m *ComposeMerger) mergeStruct(dst, src reflect.Value, keys map[string]interface{}) {
+	for i := 0; i < dst.NumField(); i++ {
+		name := jsonFieldName(dst.Type().Field(i))
+		key, ok := keys[name]
+		if !ok {
+			continue
+		}
+		d, s := dst.Field(i), src.Field(i)
+		if isNil(s) {
+			// declared without a value
+			continue
+		}
+		if isNil(d) {
+			d.Set(s)
+			continue
+		}
+
+		// nested keys are only known for mappings
+		nested, isMapping := key.(map[string]interface{})
+		switch d.Kind() {
+		case reflect.Map:
+			m.mergeMap(d, s, nested)
+			continue
+		case reflect.Ptr:
+			if d.Elem().Kind() == reflect.Struct && isMapping {
+				m.mergeStruct(d.Elem(), s.Elem(), nested)
+				continue
+			}
+		}
+
+		switch name {
+		case "ports", "expose":
+			d.Set(reflect.AppendSlice(d, s))
+		case "volumes":
+			if volumes, ok := d.Interface().(config.ServiceVolumes); ok {
+				d.Set(reflect.ValueOf(m.mergeVolumes(volumes, s.Interface().(config.ServiceVolumes))))
+			} else {
+				d.Set(s)
+			}
+		default:
+			d.Set(s)
+		}
+	}
+}
+
+// Entries of both maps with the same key are merged, e.g. two
+// definitions of the same service. Entries of maps declared as a list,
+// like `environment: [FOO=bar]`, have no nested keys and are replaced.
+func (m *ComposeMerger) mergeMap(dst, src reflect.Value, keys map[string]interface{}) {
+	for _, key := range src.MapKeys() {
+		s := src.MapIndex(key)
+		d := dst.MapIndex(key)
+		nested, isMapping := keys[key.String()].(map[string]interface{})
+		if d.IsValid() && !isNil(d) && !isNil(s) && isMapping &&
+			d.Kind() == reflect.Ptr && d.Elem().Kind() == reflect.Struct {
+			m.mergeStruct(d.Elem(), s.Elem(), nested)
+			continue
+		}
+		if d.IsValid() && !isNil(d) && isNil(s) {
+			// declared without a value
+			continue
+		}
+		dst.SetMapIndex(key, s)
+	}
+}
+
+// Volumes with the same container target are replaced, new ones appended.
+func (m *ComposeMerger) mergeVolumes(base, override config.ServiceVolumes) config.ServiceVolumes {
+	out := append(config.ServiceVolumes{}, base...)
+	for _, volume := range override {
+		replaced := false
+		for i, existing := range out {
+			if existing.Target == volume.Target {
+				out[i] = volume
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			out = append(out, volume)
+		}
+	}
+	return out
+}
+
+// Returns the keys declared by either tree, nested mappings are combined.
+func mergeKeys(base, override map[string]interface{}) map[string]interface{} {
+	keys := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		keys[k] = v
+	}
+	for k, v := range override {
+		b, baseMapping := keys[k].(map[string]interface{})
+		o, overrideMapping := v.(map[string]interface{})
+		if baseMapping && overrideMapping {
+			keys[k] = mergeKeys(b, o)
+			continue
+		}
+		keys[k] = v
+	}
+	return keys
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("json"), ",")[0]
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package converter_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sloppyio/sloppose/internal/test"
+	"github.com/sloppyio/sloppose/pkg/config"
+	"github.com/sloppyio/sloppose/pkg/converter"
+)
+
+func TestComposeMerger_Merge(t *testing.T) {
+	helper := test.NewHelper(t)
+	loader := &converter.ComposeLoader{}
+	merger := &converter.ComposeMerger{}
+	var merged *config.DockerComposeV3
+	for _, name := range []string{"fixture_merge0.yml", "fixture_merge1.yml"} {
+		r := helper.GetTestFile(name)
+		b, err := ioutil.ReadAll(r)
+		r.Close()
+		helper.Must(err)
+		doc, keys, err := loader.Load(&converter.ComposeSource{Content: b})
+		helper.Must(err)
+		merged = merger.Merge(merged, doc, keys)
+	}
+
+	for _, service := range []string{"web", "db", "cache"} {
+		if _, ok := merged.Services[service]; !ok {
+			t.Errorf("Couldn't find service %q", service)
+		}
+	}
+
+	expected := &config.Service{
+		Image:  "nginx:1.15",
+		Ports:  config.ServicePorts{{Target: "80"}, {Target: "443"}},
+		Expose: []interface{}{"9000"},
+		Environment: config.MappingWithEquals{
+			"BAR": ToStrPtr("baz"),
+			"FOO": ToStrPtr("foo"),
+			"BAZ": ToStrPtr("qux"),
+		},
+		Labels: config.Mapping{
+			"com.example.team": "web",
+			"com.example.env":  "prod",
+		},
+		Volumes: config.ServiceVolumes{
+			{Type: "volume", Source: "prod_content", Target: "/var/www/html"},
+			{Type: "volume", Source: "logs", Target: "/var/log/nginx"},
+		},
+		Deploy: &config.Deployment{
+			Replicas: 3,
+			Resources: &config.Resources{
+				Limits: &config.Limits{Memory: "128M"},
+			},
+		},
+	}
+	if diff := cmp.Diff(merged.Services["web"], expected); diff != "" {
+		t.Errorf("Result differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestComposeMerger_MergeNil(t *testing.T) {
+	merger := &converter.ComposeMerger{}
+	doc := &config.DockerComposeV3{Version: "3"}
+	if merged := merger.Merge(nil, doc, nil); merged != doc {
+		t.Errorf("Expected the override document if no base is given.")
+	}
+	if merged := merger.Merge(doc, nil, nil); merged != doc {
+		t.Errorf("Expected the base document if no override is given.")
+	}
+}
+
+func TestComposeMerger_MergeZeroValues(t *testing.T) {
+	base := `services:
+  web:
+    image: nginx
+    privileged: true
+    working_dir: /srv
+    deploy:
+      replicas: 3
+    depends_on:
+      db:
+        condition: service_started
+  db:
+    image: postgres
+`
+	cases := map[string]struct {
+		override string
+		expected *config.Service
+	}{
+		"false and 0": {
+			override: `
+    privileged: false
+    deploy:
+      replicas: 0`,
+			expected: &config.Service{
+				Image:      "nginx",
+				WorkingDir: "/srv",
+				Deploy:     &config.Deployment{},
+				DependsOn: config.DependsOn{
+					"db": {Condition: "service_started", Required: true},
+				},
+			},
+		},
+		"empty and nested false": {
+			override: `
+    working_dir: ""
+    depends_on:
+      db:
+        condition: service_started
+        required: false`,
+			expected: &config.Service{
+				Image:      "nginx",
+				Privileged: true,
+				Deploy:     &config.Deployment{Replicas: 3},
+				DependsOn: config.DependsOn{
+					"db": {Condition: "service_started", Required: false},
+				},
+			},
+		},
+		"undeclared keys": {
+			override: `
+    image: nginx:1.15`,
+			expected: &config.Service{
+				Image:      "nginx:1.15",
+				Privileged: true,
+				WorkingDir: "/srv",
+				Deploy:     &config.Deployment{Replicas: 3},
+				DependsOn: config.DependsOn{
+					"db": {Condition: "service_started", Required: true},
+				},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			loader := &converter.ComposeLoader{}
+			merger := &converter.ComposeMerger{}
+			merged, _, err := loader.Load(&converter.ComposeSource{Content: []byte(base)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc, keys, err := loader.Load(&converter.ComposeSource{Content: []byte("services:\n  web:" + c.override + "\n")})
+			if err != nil {
+				t.Fatal(err)
+			}
+			merged = merger.Merge(merged, doc, keys)
+			if diff := cmp.Diff(merged.Services["web"], c.expected); diff != "" {
+				t.Errorf("Result differs: (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,559 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sloppyio/sloppose/pkg/config"
+)
+
+// ComposeProject is the normalized form of the loaded compose files.
+// Elements accepting multiple syntaxes are resolved to a single type, so
+// the conversion stages don't have to deal with the compose formats.
+type ComposeProject struct {
+	Name     string
+	Services map[string]*ComposeService
+
+	Volumes  map[string]*ProjectVolume
+	Networks map[string]*ProjectNetwork
+	Secrets  map[string]*ProjectFile
+	Configs  map[string]*ProjectFile
+
+	// Sloppy holds the `x-sloppy` settings applying to all services.
+	Sloppy config.Extension
+}
+
+// ComposeService holds the elements of a service relevant for conversion.
+type ComposeService struct {
+	Name  string
+	Image string
+	// Profiles enabling the service, services without are always enabled.
+	Profiles []string
+	// Build is the build context of services built from source.
+	Build      string
+	Domainname string
+
+	Entrypoint  config.ShellCommand
+	Command     config.ShellCommand
+	Environment map[string]*string
+	EnvFiles    []config.EnvFile
+	Labels      map[string]string
+
+	Ports       []config.ServicePortConfig
+	Expose      []string
+	Mounts      []config.ServiceVolumeConfig
+	Healthcheck *ServiceHealthcheck
+	DependsOn   map[string]*config.ServiceDependency
+	// Links are the linked services by their alias, services linked
+	// without alias by their own name.
+	Links   map[string]string
+	Logging *ServiceLogging
+
+	Replicas          int
+	MemoryLimit       string
+	MemoryReservation string
+	Restart           string
+	UpdateConfig      *ServiceUpdateConfig
+	RestartPolicy     *ServiceRestartPolicy
+
+	// Sloppy holds the `x-sloppy` settings of the service.
+	Sloppy config.Extension
+}
+
+// ServiceHealthcheck is a healthcheck with parsed durations, zero
+// durations and retries are not set.
+type ServiceHealthcheck struct {
+	// Test in the list syntax, e.g. `["CMD", "curl", "-f", "http://localhost"]`.
+	Test        []string
+	Interval    time.Duration
+	Timeout     time.Duration
+	StartPeriod time.Duration
+	Retries     int
+	Disable     bool
+}
+
+type ServiceLogging struct {
+	Driver  string
+	Options map[string]string
+}
+
+// ServiceUpdateConfig is the `deploy.update_config` of a service with
+// parsed durations.
+type ServiceUpdateConfig struct {
+	Parallelism     int
+	Delay           time.Duration
+	FailureAction   string
+	Monitor         time.Duration
+	MaxFailureRatio float64
+	// Order is `stop-first` or `start-first`.
+	Order string
+}
+
+// ServiceRestartPolicy is the `deploy.restart_policy` of a service with
+// parsed durations.
+type ServiceRestartPolicy struct {
+	// Condition is `none`, `on-failure` or `any`.
+	Condition   string
+	Delay       time.Duration
+	MaxAttempts int
+	Window      time.Duration
+}
+
+// ProjectVolume is a top-level volume.
+type ProjectVolume struct {
+	// Name is the name of the volume outside of the project, if set.
+	Name       string
+	Driver     string
+	DriverOpts map[string]string
+	Labels     map[string]string
+	// External volumes are created outside of the project, the legacy
+	// syntax `external: {name: data}` sets the name as well.
+	External bool
+}
+
+// ProjectNetwork is a top-level network.
+type ProjectNetwork struct {
+	Name       string
+	Driver     string
+	DriverOpts map[string]string
+	Labels     map[string]string
+	External   bool
+	Internal   bool
+}
+
+// ProjectFile is a top-level secret or config.
+type ProjectFile struct {
+	Name string
+	// File is the path of the content, empty for external ones.
+	File     string
+	Labels   map[string]string
+	External bool
+}
+
+// NewComposeProject normalizes the given loaded compose document.
+func NewComposeProject(name string, doc *config.DockerComposeV3) (*ComposeProject, error) {
+	project := &ComposeProject{
+		Name:     name,
+		Services: make(map[string]*ComposeService),
+		Volumes:  make(map[string]*ProjectVolume, len(doc.Volumes)),
+		Networks: make(map[string]*ProjectNetwork, len(doc.Networks)),
+		Secrets:  make(map[string]*ProjectFile, len(doc.Secrets)),
+		Configs:  make(map[string]*ProjectFile, len(doc.Configs)),
+		Sloppy:   doc.XSloppy,
+	}
+	// resources may be declared without any settings, e.g. `data:`
+	for name, in := range doc.Volumes {
+		volume := &ProjectVolume{}
+		if in != nil {
+			volume.Name = in.Name
+			volume.Driver = in.Driver
+			volume.DriverOpts = stringMap(in.DriverOpts)
+			volume.Labels = in.Labels
+			volume.External = externalResource(in.External, &volume.Name)
+		}
+		project.Volumes[name] = volume
+	}
+	for name, in := range doc.Networks {
+		network := &ProjectNetwork{}
+		if in != nil {
+			network.Name = in.Name
+			network.Driver = in.Driver
+			network.DriverOpts = stringMap(in.DriverOpts)
+			network.Labels = in.Labels
+			network.External = externalResource(in.External, &network.Name)
+			network.Internal = in.Internal
+		}
+		project.Networks[name] = network
+	}
+	for name, in := range doc.Secrets {
+		secret := &ProjectFile{}
+		if in != nil {
+			secret.Name = in.Name
+			secret.File = in.File
+			secret.Labels = in.Labels
+			secret.External = externalResource(in.External, &secret.Name)
+		}
+		project.Secrets[name] = secret
+	}
+	for name, in := range doc.Configs {
+		c := &ProjectFile{}
+		if in != nil {
+			c.Name = in.Name
+			c.File = in.File
+			c.Labels = in.Labels
+			c.External = externalResource(in.External, &c.Name)
+		}
+		project.Configs[name] = c
+	}
+	for name, service := range doc.Services {
+		s, err := newComposeService(name, service)
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %v", name, err)
+		}
+		project.Services[name] = s
+	}
+	return project, nil
+}
+
+// ServiceNames returns the names of all services in alphabetical order.
+func (p *ComposeProject) ServiceNames() []string {
+	var names []string
+	for name := range p.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProfiles removes the services which are not enabled by one of the
+// given profiles, `*` enables all. Enabled services must not depend on
+// removed ones.
+func (p *ComposeProject) ApplyProfiles(profiles []string) error {
+	active := make(map[string]bool)
+	for _, profile := range profiles {
+		active[profile] = true
+	}
+	enabled := func(service *ComposeService) bool {
+		if len(service.Profiles) == 0 || active["*"] {
+			return true
+		}
+		for _, profile := range service.Profiles {
+			if active[profile] {
+				return true
+			}
+		}
+		return false
+	}
+
+	disabled := make(map[string]*ComposeService)
+	for name, service := range p.Services {
+		if !enabled(service) {
+			disabled[name] = service
+		}
+	}
+
+	for _, name := range p.ServiceNames() {
+		service := p.Services[name]
+		if disabled[name] != nil {
+			continue
+		}
+		for _, dep := range service.Dependencies() {
+			if d, ok := disabled[dep]; ok {
+				return fmt.Errorf("service %q depends on %q, which is only enabled by the profiles %s",
+					name, dep, strings.Join(d.Profiles, ", "))
+			}
+		}
+	}
+
+	for name := range disabled {
+		delete(p.Services, name)
+	}
+	return nil
+}
+
+// SelectServices keeps the given services and all services they need,
+// all if none are given, and removes the excluded ones afterwards. Needed
+// services are found by `depends_on`, `links` and the environment values
+// referring to other services. Returns a warning for each remaining
+// reference to an excluded service, those references are dropped.
+func (p *ComposeProject) SelectServices(names, exclude []string) ([]string, error) {
+	for _, name := range append(append([]string{}, names...), exclude...) {
+		if _, ok := p.Services[name]; !ok {
+			return nil, fmt.Errorf("no such service: %q", name)
+		}
+	}
+
+	if len(names) > 0 {
+		selected := make(map[string]bool)
+		queue := append([]string{}, names...)
+		for len(queue) > 0 {
+			name := queue[0]
+			queue = queue[1:]
+			if selected[name] {
+				continue
+			}
+			selected[name] = true
+			for _, ref := range p.references(name) {
+				if _, ok := p.Services[ref]; ok && !selected[ref] {
+					queue = append(queue, ref)
+				}
+			}
+		}
+		for name := range p.Services {
+			if !selected[name] {
+				delete(p.Services, name)
+			}
+		}
+	}
+
+	excluded := make(map[string]bool)
+	for _, name := range exclude {
+		excluded[name] = true
+		delete(p.Services, name)
+	}
+
+	var warnings []string
+	for _, name := range p.ServiceNames() {
+		service := p.Services[name]
+		for _, ref := range p.references(name) {
+			if excluded[ref] {
+				warnings = append(warnings, fmt.Sprintf("Service %q refers to the excluded service %q.", name, ref))
+			}
+		}
+		for dep := range service.DependsOn {
+			if excluded[dep] {
+				delete(service.DependsOn, dep)
+			}
+		}
+		for alias, link := range service.Links {
+			if excluded[link] {
+				delete(service.Links, alias)
+			}
+		}
+	}
+	return warnings, nil
+}
+
+// Returns the services the given service needs, excluded ones included.
+func (p *ComposeProject) references(name string) []string {
+	service := p.Services[name]
+	linker := &Linker{}
+	seen := make(map[string]bool)
+	var refs []string
+	for _, ref := range append(service.Dependencies(), linker.References(service)...) {
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// Dependencies returns the names of the services the service depends on
+// by `depends_on` or `links` in alphabetical order. Optional dependencies
+// (`required: false`) are left out.
+func (s *ComposeService) Dependencies() []string {
+	seen := make(map[string]bool)
+	for name, dep := range s.DependsOn {
+		if dep == nil || dep.Required {
+			seen[name] = true
+		}
+	}
+	for _, link := range s.Links {
+		seen[link] = true
+	}
+
+	var names []string
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Returns the service the given host name refers to, the linked service
+// if it's the alias of a link.
+func (s *ComposeService) linkedService(host string) string {
+	if s != nil {
+		if link, ok := s.Links[host]; ok {
+			return link
+		}
+	}
+	return host
+}
+
+func newComposeService(name string, in *config.Service) (*ComposeService, error) {
+	service := &ComposeService{
+		Name:       name,
+		Image:      in.Image,
+		Profiles:   in.Profiles,
+		Domainname: in.Domainname,
+		Entrypoint: in.Entrypoint,
+		Command:    in.Command,
+		EnvFiles:   in.EnvFile,
+		Labels:     in.Labels,
+		Ports:      in.Ports,
+		Mounts:     in.Volumes,
+		Restart:    in.Restart,
+		Sloppy:     in.XSloppy,
+	}
+
+	if in.Environment != nil {
+		service.Environment = make(map[string]*string, len(in.Environment))
+		for key, val := range in.Environment {
+			service.Environment[key] = val
+		}
+	}
+
+	if in.Links != nil {
+		service.Links = make(map[string]string, len(in.Links))
+		for _, link := range in.Links {
+			// `service:alias`
+			parts := strings.SplitN(link, ":", 2)
+			alias := parts[len(parts)-1]
+			service.Links[alias] = parts[0]
+		}
+	}
+
+	if in.DependsOn != nil {
+		service.DependsOn = make(map[string]*config.ServiceDependency, len(in.DependsOn))
+		for name, dep := range in.DependsOn {
+			service.DependsOn[name] = dep
+		}
+	}
+
+	switch build := in.Build.(type) {
+	case string:
+		service.Build = build
+	case map[string]interface{}:
+		service.Build, _ = build["context"].(string)
+		if service.Build == "" {
+			service.Build = "."
+		}
+	}
+
+	for _, port := range in.Expose {
+		service.Expose = append(service.Expose, fmt.Sprint(port))
+	}
+
+	if in.Healthcheck != nil {
+		healthcheck, err := newServiceHealthcheck(in.Healthcheck)
+		if err != nil {
+			return nil, err
+		}
+		service.Healthcheck = healthcheck
+	}
+
+	if in.Logging != nil {
+		service.Logging = &ServiceLogging{Driver: in.Logging.Driver}
+		if options, ok := in.Logging.Options.(map[string]interface{}); ok {
+			service.Logging.Options = make(map[string]string)
+			for key, val := range options {
+				service.Logging.Options[key] = fmt.Sprint(val)
+			}
+		}
+	}
+
+	if in.Deploy != nil {
+		service.Replicas = in.Deploy.Replicas
+		if resources := in.Deploy.Resources; resources != nil {
+			if resources.Limits != nil {
+				service.MemoryLimit = resources.Limits.Memory
+			}
+			if resources.Reservations != nil {
+				service.MemoryReservation = resources.Reservations.Memory
+			}
+		}
+		if update := in.Deploy.UpdateConfig; update != nil {
+			service.UpdateConfig = &ServiceUpdateConfig{
+				Parallelism:     update.Parallelism,
+				FailureAction:   update.FailureAction,
+				MaxFailureRatio: update.MaxFailureRatio,
+				Order:           update.Order,
+			}
+			err := parseDurations("update_config", []namedDuration{
+				{"delay", update.Delay, &service.UpdateConfig.Delay},
+				{"monitor", update.Monitor, &service.UpdateConfig.Monitor},
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if policy := in.Deploy.RestartPolicy; policy != nil {
+			service.RestartPolicy = &ServiceRestartPolicy{
+				Condition:   policy.Condition,
+				MaxAttempts: policy.MaxAttempts,
+			}
+			err := parseDurations("restart_policy", []namedDuration{
+				{"delay", policy.Delay, &service.RestartPolicy.Delay},
+				{"window", policy.Window, &service.RestartPolicy.Window},
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return service, nil
+}
+
+// Returns whether the resource is external. The name of the legacy
+// syntax `external: {name: data}` is set on name unless it's set.
+func externalResource(external interface{}, name *string) bool {
+	switch external := external.(type) {
+	case bool:
+		return external
+	case map[string]interface{}:
+		if n, ok := external["name"].(string); ok && *name == "" {
+			*name = n
+		}
+		return true
+	}
+	return false
+}
+
+// Returns the options like `driver_opts` with their values as strings.
+func stringMap(options interface{}) map[string]string {
+	m, ok := options.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for key, val := range m {
+		// yaml numbers are decoded as float64, large ones would be
+		// printed with an exponent
+		if f, ok := val.(float64); ok {
+			out[key] = strconv.FormatFloat(f, 'f', -1, 64)
+			continue
+		}
+		out[key] = fmt.Sprint(val)
+	}
+	return out
+}
+
+// namedDuration is a duration of a compose element to be parsed into out.
+type namedDuration struct {
+	name  string
+	value string
+	out   *time.Duration
+}
+
+// Parses the given durations, unset ones are skipped.
+func parseDurations(element string, durations []namedDuration) error {
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(d.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %s %q", element, d.name, d.value)
+		}
+		*d.out = duration
+	}
+	return nil
+}
+
+func newServiceHealthcheck(in *config.Healthcheck) (*ServiceHealthcheck, error) {
+	healthcheck := &ServiceHealthcheck{
+		Test:    in.Test,
+		Retries: int(in.Retries),
+		Disable: in.Disable,
+	}
+	// `test: ["NONE"]` disables the healthcheck of the image
+	if len(in.Test) > 0 && in.Test[0] == "NONE" {
+		healthcheck.Disable = true
+	}
+
+	err := parseDurations("healthcheck", []namedDuration{
+		{"interval", in.Interval, &healthcheck.Interval},
+		{"timeout", in.Timeout, &healthcheck.Timeout},
+		{"start_period", in.StartPeriod, &healthcheck.StartPeriod},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return healthcheck, nil
+}
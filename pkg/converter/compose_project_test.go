@@ -0,0 +1,293 @@
+package converter_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sloppyio/sloppose/pkg/config"
+	"github.com/sloppyio/sloppose/pkg/converter"
+)
+
+func TestNewComposeProject(t *testing.T) {
+	buf := []byte(`version: "3.9"
+services:
+  web:
+    build:
+      dockerfile: Dockerfile.web
+    expose:
+    - 8080
+    - "9000"
+    logging:
+      driver: syslog
+      options:
+        syslog-address: udp://localhost:514
+    healthcheck:
+      test: curl -f http://localhost
+      interval: 1m30s
+      timeout: 10s
+      retries: 3
+    deploy:
+      replicas: 2
+      resources:
+        limits:
+          memory: 256M
+        reservations:
+          memory: 128M
+      update_config:
+        parallelism: 2
+        delay: 10s
+        order: start-first
+      restart_policy:
+        condition: on-failure
+        max_attempts: 3
+        window: 2m
+volumes:
+  data:
+  backups:
+    driver_opts:
+      size: 10
+    external:
+      name: shared_backups
+networks:
+  front:
+    internal: true
+secrets:
+  token:
+    file: ./token.txt
+configs:
+  nginx:
+    external: true
+`)
+	loader := &converter.ComposeLoader{}
+	doc, _, err := loader.Load(&converter.ComposeSource{Content: buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	project, err := converter.NewComposeProject("test", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &converter.ComposeService{
+		Name:   "web",
+		Build:  ".",
+		Expose: []string{"8080", "9000"},
+		Logging: &converter.ServiceLogging{
+			Driver:  "syslog",
+			Options: map[string]string{"syslog-address": "udp://localhost:514"},
+		},
+		Healthcheck: &converter.ServiceHealthcheck{
+			Test:     []string{"CMD-SHELL", "curl -f http://localhost"},
+			Interval: 90 * time.Second,
+			Timeout:  10 * time.Second,
+			Retries:  3,
+		},
+		Replicas:          2,
+		MemoryLimit:       "256M",
+		MemoryReservation: "128M",
+		UpdateConfig: &converter.ServiceUpdateConfig{
+			Parallelism: 2,
+			Delay:       10 * time.Second,
+			Order:       "start-first",
+		},
+		RestartPolicy: &converter.ServiceRestartPolicy{
+			Condition:   "on-failure",
+			MaxAttempts: 3,
+			Window:      2 * time.Minute,
+		},
+	}
+	if diff := cmp.Diff(project.Services["web"], expected); diff != "" {
+		t.Errorf("Result differs: (-got +want)\n%s", diff)
+	}
+
+	volumes := map[string]*converter.ProjectVolume{
+		"data": {},
+		"backups": {
+			Name:       "shared_backups",
+			DriverOpts: map[string]string{"size": "10"},
+			External:   true,
+		},
+	}
+	if diff := cmp.Diff(project.Volumes, volumes); diff != "" {
+		t.Errorf("Volumes differ: (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(project.Networks, map[string]*converter.ProjectNetwork{"front": {Internal: true}}); diff != "" {
+		t.Errorf("Networks differ: (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(project.Secrets, map[string]*converter.ProjectFile{"token": {File: "./token.txt"}}); diff != "" {
+		t.Errorf("Secrets differ: (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(project.Configs, map[string]*converter.ProjectFile{"nginx": {External: true}}); diff != "" {
+		t.Errorf("Configs differ: (-got +want)\n%s", diff)
+	}
+}
+
+func TestNewComposeProjectInvalidDuration(t *testing.T) {
+	doc := &config.DockerComposeV3{
+		Services: map[string]*config.Service{
+			"web": {Healthcheck: &config.Healthcheck{Interval: "often"}},
+		},
+	}
+	_, err := converter.NewComposeProject("test", doc)
+	if err == nil || err.Error() != `service "web": invalid healthcheck interval "often"` {
+		t.Errorf("Expected an error due to the invalid interval, got %v", err)
+	}
+}
+
+func TestComposeProject_ApplyProfiles(t *testing.T) {
+	cases := map[string]struct {
+		profiles []string
+		env      string
+		expected []string
+		err      string
+	}{
+		"no profiles": {
+			expected: []string{"api", "web"},
+		},
+		"single profile": {
+			profiles: []string{"seed"},
+			expected: []string{"api", "seeder", "web"},
+		},
+		"repeated profiles": {
+			profiles: []string{"debug", "tools"},
+			expected: []string{"admin", "api", "debug", "seeder", "web"},
+		},
+		"all profiles": {
+			profiles: []string{"*"},
+			expected: []string{"admin", "api", "debug", "seeder", "web"},
+		},
+		"COMPOSE_PROFILES": {
+			env:      "debug,seed",
+			expected: []string{"api", "debug", "seeder", "web"},
+		},
+		"dependency into disabled profile": {
+			profiles: []string{"tools"},
+			err:      `service "admin" depends on "debug", which is only enabled by the profiles debug`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if c.env != "" {
+				os.Setenv(converter.EnvComposeProfiles, c.env)
+				defer os.Unsetenv(converter.EnvComposeProfiles)
+			}
+			reader := &converter.ComposeReader{}
+			source, err := reader.ReadSource("testdata/profiles/compose.yml")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cf, err := converter.NewComposeFileWithOptions(
+				[]*converter.ComposeSource{source},
+				&converter.ComposeOptions{Profiles: c.profiles},
+			)
+			if c.err != "" {
+				if err == nil || err.Error() != c.err {
+					t.Errorf("Expected %q, got %v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(cf.Project.ServiceNames(), c.expected); diff != "" {
+				t.Errorf("Result differs: (-got +want)\n%s", diff)
+			}
+
+			sf, err := converter.NewSloppyFile(cf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			linker := &converter.Linker{}
+			if err := linker.Resolve(cf, sf); err != nil {
+				t.Fatal(err)
+			}
+			if len(sf.Services["apps"]) != len(c.expected) {
+				t.Errorf("Expected %d apps, got %d", len(c.expected), len(sf.Services["apps"]))
+			}
+		})
+	}
+}
+
+func TestComposeProject_SelectServices(t *testing.T) {
+	cases := map[string]struct {
+		services []string
+		exclude  []string
+		expected []string
+		warnings []string
+		err      string
+	}{
+		"all": {
+			expected: []string{"admin", "api", "cache", "db", "queue", "web", "worker"},
+		},
+		"depends_on, links and environment": {
+			services: []string{"web"},
+			expected: []string{"api", "cache", "db", "web"},
+		},
+		"multiple services": {
+			services: []string{"admin", "worker"},
+			expected: []string{"admin", "db", "queue", "worker"},
+		},
+		"exclude": {
+			services: []string{"web"},
+			exclude:  []string{"db", "cache"},
+			expected: []string{"api", "web"},
+			warnings: []string{
+				`Service "api" refers to the excluded service "cache".`,
+				`Service "api" refers to the excluded service "db".`,
+			},
+		},
+		"exclude without selection": {
+			exclude:  []string{"queue"},
+			expected: []string{"admin", "api", "cache", "db", "web", "worker"},
+			warnings: []string{`Service "worker" refers to the excluded service "queue".`},
+		},
+		"unknown service": {
+			services: []string{"frontend"},
+			err:      `no such service: "frontend"`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			reader := &converter.ComposeReader{}
+			source, err := reader.ReadSource("testdata/services/compose.yml")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cf, err := converter.NewComposeFileWithOptions(
+				[]*converter.ComposeSource{source},
+				&converter.ComposeOptions{Services: c.services, Exclude: c.exclude},
+			)
+			if c.err != "" {
+				if err == nil || err.Error() != c.err {
+					t.Errorf("Expected %q, got %v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(cf.Project.ServiceNames(), c.expected); diff != "" {
+				t.Errorf("Services differ: (-got +want)\n%s", diff)
+			}
+			if diff := cmp.Diff(cf.Warnings, c.warnings); diff != "" {
+				t.Errorf("Warnings differ: (-got +want)\n%s", diff)
+			}
+
+			sf, err := converter.NewSloppyFile(cf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			linker := &converter.Linker{}
+			if err := linker.Resolve(cf, sf); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
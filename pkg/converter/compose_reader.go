@@ -1,15 +1,36 @@
 package converter
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 const (
-	defaultFileName = "docker-compose.yml"
+	EnvComposeFile          = "COMPOSE_FILE"
+	EnvComposePathSeparator = "COMPOSE_PATH_SEPARATOR"
 )
 
+var (
+	// DefaultFileNames are looked up in this order, like docker-compose does.
+	DefaultFileNames = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+	// DefaultOverrideFileNames are applied on top of a default file
+	// found in the same directory.
+	DefaultOverrideFileNames = []string{"compose.override.yaml", "compose.override.yml", "docker-compose.override.yaml", "docker-compose.override.yml"}
+
+	ErrNoComposeFile = errors.New("no compose file found, looked for " + strings.Join(DefaultFileNames, ", ") +
+		" in the current and parent directories")
+)
+
+// ComposeSource is the content of a single compose file and the
+// absolute path it was read from.
+type ComposeSource struct {
+	Filename string
+	Content  []byte
+}
+
 type ComposeReader struct{}
 
 func (cr *ComposeReader) Read(filename string) ([]byte, error) {
@@ -30,3 +51,83 @@ func (cr *ComposeReader) Read(filename string) ([]byte, error) {
 
 	return bytes, nil
 }
+
+// ReadSource reads the given file and keeps track of its location.
+// Relative paths are resolved from the working directory.
+func (cr *ComposeReader) ReadSource(filename string) (*ComposeSource, error) {
+	if !filepath.IsAbs(filename) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		filename = filepath.Join(cwd, filename)
+	}
+
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ComposeSource{
+		Filename: filename,
+		Content:  buf,
+	}, nil
+}
+
+// DefaultFiles returns the files to use if none are given. Those listed
+// by `COMPOSE_FILE` come first, otherwise the first of DefaultFileNames
+// found in the working directory or its parents, followed by an override
+// file next to it.
+func (cr *ComposeReader) DefaultFiles() ([]string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	// like docker-compose, COMPOSE_FILE may also be set in the `.env` file
+	environment, err := readEnvironment([]string{filepath.Join(cwd, dotEnvFileName)}, true)
+	if err != nil {
+		return nil, err
+	}
+	if composeFile := environment[EnvComposeFile]; composeFile != "" {
+		separator := environment[EnvComposePathSeparator]
+		if separator == "" {
+			separator = string(os.PathListSeparator)
+		}
+		var files []string
+		for _, file := range strings.Split(composeFile, separator) {
+			if file == "" {
+				continue
+			}
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(cwd, file)
+			}
+			files = append(files, file)
+		}
+		return files, nil
+	}
+
+	for dir := cwd; ; dir = filepath.Dir(dir) {
+		if file, ok := cr.lookup(dir, DefaultFileNames); ok {
+			files := []string{file}
+			if override, ok := cr.lookup(dir, DefaultOverrideFileNames); ok {
+				files = append(files, override)
+			}
+			return files, nil
+		}
+		if dir == filepath.Dir(dir) {
+			return nil, ErrNoComposeFile
+		}
+	}
+}
+
+// Returns the first of the given files existing in dir.
+func (cr *ComposeReader) lookup(dir string, names []string) (string, bool) {
+	for _, name := range names {
+		file := filepath.Join(dir, name)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file, true
+		}
+	}
+	return "", false
+}
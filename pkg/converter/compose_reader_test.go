@@ -0,0 +1,115 @@
+package converter_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sloppyio/sloppose/internal/test"
+	"github.com/sloppyio/sloppose/pkg/converter"
+)
+
+func TestComposeReader_DefaultFiles(t *testing.T) {
+	cases := map[string]struct {
+		files    []string
+		env      map[string]string
+		dir      string
+		expected []string
+	}{
+		"compose.yaml first": {
+			files:    []string{"docker-compose.yml", "compose.yml", "compose.yaml"},
+			expected: []string{"compose.yaml"},
+		},
+		"yaml variant": {
+			files:    []string{"docker-compose.yaml"},
+			expected: []string{"docker-compose.yaml"},
+		},
+		"override": {
+			files:    []string{"docker-compose.yml", "docker-compose.override.yml"},
+			expected: []string{"docker-compose.yml", "docker-compose.override.yml"},
+		},
+		"parent directory": {
+			files:    []string{"docker-compose.yml", "docker-compose.override.yml", "app/src/main.go"},
+			dir:      "app/src",
+			expected: []string{"docker-compose.yml", "docker-compose.override.yml"},
+		},
+		"COMPOSE_FILE": {
+			files:    []string{"docker-compose.yml"},
+			env:      map[string]string{converter.EnvComposeFile: "a.yml:b.yml"},
+			expected: []string{"a.yml", "b.yml"},
+		},
+		"COMPOSE_PATH_SEPARATOR": {
+			files: []string{"docker-compose.yml"},
+			env: map[string]string{
+				converter.EnvComposeFile:          "a.yml;b.yml",
+				converter.EnvComposePathSeparator: ";",
+			},
+			expected: []string{"a.yml", "b.yml"},
+		},
+		"COMPOSE_FILE in .env": {
+			files:    []string{"docker-compose.yml", ".env"},
+			expected: []string{"prod.yml"},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			helper := test.NewHelper(t)
+			root, err := ioutil.TempDir("", "sloppose")
+			helper.Must(err)
+			defer os.RemoveAll(root)
+			root, err = filepath.EvalSymlinks(root)
+			helper.Must(err)
+
+			for _, file := range c.files {
+				path := filepath.Join(root, file)
+				helper.Must(os.MkdirAll(filepath.Dir(path), 0755))
+				content := ""
+				if file == ".env" {
+					content = converter.EnvComposeFile + "=prod.yml\n"
+				}
+				helper.Must(ioutil.WriteFile(path, []byte(content), 0644))
+			}
+			for key, val := range c.env {
+				os.Setenv(key, val)
+				defer os.Unsetenv(key)
+			}
+			helper.Must(os.Chdir(filepath.Join(root, c.dir)))
+			defer helper.ChdirTest()
+
+			reader := &converter.ComposeReader{}
+			files, err := reader.DefaultFiles()
+			helper.Must(err)
+
+			var expected []string
+			for _, file := range c.expected {
+				if c.env[converter.EnvComposeFile] != "" {
+					file = filepath.Join(root, c.dir, file)
+				} else {
+					file = filepath.Join(root, file)
+				}
+				expected = append(expected, file)
+			}
+			if diff := cmp.Diff(files, expected); diff != "" {
+				t.Errorf("Result differs: (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestComposeReader_DefaultFilesNotFound(t *testing.T) {
+	helper := test.NewHelper(t)
+	root, err := ioutil.TempDir("", "sloppose")
+	helper.Must(err)
+	defer os.RemoveAll(root)
+	helper.Must(os.Chdir(root))
+	defer helper.ChdirTest()
+
+	reader := &converter.ComposeReader{}
+	if _, err := reader.DefaultFiles(); err != converter.ErrNoComposeFile {
+		t.Errorf("Expected %v, got %v", converter.ErrNoComposeFile, err)
+	}
+}
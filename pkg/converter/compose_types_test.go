@@ -0,0 +1,115 @@
+package converter_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sloppyio/sloppose/pkg/config"
+	"github.com/sloppyio/sloppose/pkg/converter"
+)
+
+func TestComposeLoader_UnionTypes(t *testing.T) {
+	cases := map[string]struct {
+		service  string
+		expected *config.Service
+	}{
+		"command string": {
+			service: `command: sh -c 'echo "hello world"' \$HOME`,
+			expected: &config.Service{
+				Command: config.ShellCommand{Shell: `sh -c 'echo "hello world"' \$HOME`},
+			},
+		},
+		"entrypoint list": {
+			service: `entrypoint: ["/bin/sh", "-c"]`,
+			expected: &config.Service{
+				Entrypoint: config.ShellCommand{Args: []string{"/bin/sh", "-c"}},
+			},
+		},
+		"healthcheck string": {
+			service: "healthcheck:\n      test: curl -f http://localhost",
+			expected: &config.Service{
+				Healthcheck: &config.Healthcheck{
+					Test: config.HealthcheckTest{"CMD-SHELL", "curl -f http://localhost"},
+				},
+			},
+		},
+		"environment list": {
+			service: "environment:\n    - A=b=c\n    - BARE",
+			expected: &config.Service{
+				Environment: config.MappingWithEquals{"A": ToStrPtr("b=c"), "BARE": nil},
+			},
+		},
+		"environment dict": {
+			service: "environment:\n      PORT: 80\n      RATIO: 0.5\n      BARE:",
+			expected: &config.Service{
+				Environment: config.MappingWithEquals{"PORT": ToStrPtr("80"), "RATIO": ToStrPtr("0.5"), "BARE": nil},
+			},
+		},
+		"labels list": {
+			service: "labels:\n    - com.example=web\n    - empty",
+			expected: &config.Service{
+				Labels: config.Mapping{"com.example": "web", "empty": ""},
+			},
+		},
+		"dns string": {
+			service: "dns: 8.8.8.8",
+			expected: &config.Service{
+				Dns: config.StringOrList{"8.8.8.8"},
+			},
+		},
+		"ports": {
+			service: "ports:\n    - 80\n    - 127.0.0.1:8080:80/udp\n    - 9000-9001:9000-9001\n    - target: 443\n      published: 8443\n      mode: host",
+			expected: &config.Service{
+				Ports: config.ServicePorts{
+					{Target: "80"},
+					{HostIP: "127.0.0.1", Published: "8080", Target: "80", Protocol: "udp"},
+					{Published: "9000-9001", Target: "9000-9001"},
+					{Mode: "host", Published: "8443", Target: "443"},
+				},
+			},
+		},
+		"volumes": {
+			service: "volumes:\n    - /var/lib/data\n    - data:/data:ro,nocopy\n    - ./html:/html:cached\n    - type: tmpfs\n      target: /tmp\n      tmpfs:\n        size: 1000",
+			expected: &config.Service{
+				Volumes: config.ServiceVolumes{
+					{Type: "volume", Target: "/var/lib/data"},
+					{Type: "volume", Source: "data", Target: "/data", ReadOnly: true, Volume: &config.ServiceVolumeVolume{NoCopy: true}},
+					{Type: "bind", Source: "./html", Target: "/html", Consistency: "cached"},
+					{Type: "tmpfs", Target: "/tmp", Tmpfs: &config.ServiceVolumeTmpfs{Size: "1000"}},
+				},
+			},
+		},
+		"secrets": {
+			service: "secrets:\n    - token\n    - source: cert\n      target: /certs/cert.pem\n      mode: 0440",
+			expected: &config.Service{
+				Secrets: config.ServiceFileReferences{
+					{Source: "token"},
+					{Source: "cert", Target: "/certs/cert.pem", Mode: 288},
+				},
+			},
+		},
+		"ulimits": {
+			service: "ulimits:\n      nproc: 65535\n      nofile:\n        soft: 20000\n        hard: 40000",
+			expected: &config.Service{
+				Ulimits: config.Ulimits{
+					"nproc":  {Soft: 65535, Hard: 65535},
+					"nofile": {Soft: 20000, Hard: 40000},
+				},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			loader := &converter.ComposeLoader{}
+			doc, _, err := loader.Load(&converter.ComposeSource{Content: []byte("version: \"3.9\"\nservices:\n  web:\n    " + c.service + "\n")})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(doc.Services["web"], c.expected); diff != "" {
+				t.Errorf("Result differs: (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+
+	sloppy "github.com/sloppyio/cli/pkg/api"
+)
+
+const (
+	restartNo             = "no"
+	restartConditionNone  = "none"
+	updateOrderStartFirst = "start-first"
+	updateOrderStopFirst  = "stop-first"
+)
+
+// Returns whether the service runs once instead of being kept running,
+// declared by `restart: "no"` or the restart policy condition `none`.
+func (s *ComposeService) isOneShot() bool {
+	if s.Restart == restartNo {
+		return true
+	}
+	return s.RestartPolicy != nil && s.RestartPolicy.Condition == restartConditionNone
+}
+
+// Converts the update and restart settings of the service. The update
+// order decides about rolling deploys, a parallelism below the replicas
+// replaces the instances in batches which is a rolling deploy as well.
+// sloppy.io keeps all apps running, one-shot services and settings
+// without equivalent are reported.
+func (sf *SloppyFile) convertDeploy(name string, service *ComposeService) *bool {
+	if service.isOneShot() {
+		sf.Warnings = append(sf.Warnings, fmt.Sprintf(
+			"Service %q is a one-shot task, but sloppy.io restarts apps whenever they exit. Exclude it or run it elsewhere.", name))
+	}
+	if policy := service.RestartPolicy; policy != nil {
+		var dropped []string
+		if policy.Delay != 0 {
+			dropped = append(dropped, "delay")
+		}
+		if policy.MaxAttempts != 0 {
+			dropped = append(dropped, "max_attempts")
+		}
+		if policy.Window != 0 {
+			dropped = append(dropped, "window")
+		}
+		sf.warnDropped(name, "restart_policy", dropped)
+	}
+
+	update := service.UpdateConfig
+	if update == nil {
+		return nil
+	}
+	var dropped []string
+	if update.Delay != 0 {
+		dropped = append(dropped, "delay")
+	}
+	if update.FailureAction != "" {
+		dropped = append(dropped, "failure_action")
+	}
+	if update.MaxFailureRatio != 0 {
+		dropped = append(dropped, "max_failure_ratio")
+	}
+	if update.Monitor != 0 {
+		dropped = append(dropped, "monitor")
+	}
+	sf.warnDropped(name, "update_config", dropped)
+
+	switch {
+	case update.Order == updateOrderStartFirst:
+		return sloppy.Bool(true)
+	case update.Order == updateOrderStopFirst:
+		return sloppy.Bool(false)
+	case update.Parallelism > 0 && update.Parallelism < service.Replicas:
+		return sloppy.Bool(true)
+	}
+	return nil
+}
+
+func (sf *SloppyFile) warnDropped(name, block string, settings []string) {
+	if len(settings) == 0 {
+		return
+	}
+	sf.Warnings = append(sf.Warnings, fmt.Sprintf(
+		"Service %q: the %s settings %s have no equivalent on sloppy.io and are dropped.",
+		name, block, strings.Join(settings, ", ")))
+}
@@ -0,0 +1,76 @@
+package converter_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	sloppy "github.com/sloppyio/cli/pkg/api"
+	"github.com/sloppyio/sloppose/pkg/converter"
+)
+
+func TestNewSloppyFileDeploy(t *testing.T) {
+	buf := []byte(`services:
+  batches:
+    image: nginx
+    deploy:
+      replicas: 4
+      update_config:
+        parallelism: 2
+  migrate:
+    image: app
+    restart: "no"
+  seed:
+    image: app
+    deploy:
+      restart_policy:
+        condition: none
+  stop:
+    image: postgres
+    deploy:
+      update_config:
+        order: stop-first
+  web:
+    image: nginx
+    restart: always
+    deploy:
+      update_config:
+        order: start-first
+        delay: 10s
+        failure_action: rollback
+      restart_policy:
+        condition: on-failure
+        max_attempts: 3
+`)
+	cf, err := converter.NewComposeFile(buf, "deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf, err := converter.NewSloppyFile(cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]*bool{
+		"batches": sloppy.Bool(true),
+		"migrate": nil,
+		"seed":    nil,
+		"stop":    sloppy.Bool(false),
+		"web":     sloppy.Bool(true),
+	}
+	for name, want := range expected {
+		if diff := cmp.Diff(sf.Services["apps"][name].ForceRollingDeploy, want); diff != "" {
+			t.Errorf("ForceRollingDeploy of %q differs: (-got +want)\n%s", name, diff)
+		}
+	}
+
+	warnings := []string{
+		`Service "migrate" is a one-shot task, but sloppy.io restarts apps whenever they exit. Exclude it or run it elsewhere.`,
+		`Service "seed" is a one-shot task, but sloppy.io restarts apps whenever they exit. Exclude it or run it elsewhere.`,
+		`Service "web": the restart_policy settings max_attempts have no equivalent on sloppy.io and are dropped.`,
+		`Service "web": the update_config settings delay, failure_action have no equivalent on sloppy.io and are dropped.`,
+	}
+	if diff := cmp.Diff(sf.Warnings, warnings); diff != "" {
+		t.Errorf("Warnings differ: (-got +want)\n%s", diff)
+	}
+}
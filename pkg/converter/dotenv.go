@@ -0,0 +1,133 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var dotEnvKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// parseDotEnv parses the dotenv syntax of env files the way docker-compose
+// does:
+//
+//	# comment
+//	export KEY=value          # optional export prefix, inline comment
+//	KEY='literal ${NOT_SET}'  # single quotes keep everything as written
+//	KEY="line\nbreak $OTHER"  # double quotes support escapes
+//	KEY="spans
+//	multiple lines"
+//	KEY                       # no value, taken from the environment
+//
+// Variables of unquoted and double quoted values are substituted from the
+// keys defined before and the given environment. Keys without a value map
+// to nil.
+func parseDotEnv(content []byte, environment map[string]string) (map[string]*string, error) {
+	src := strings.Replace(string(content), "\r\n", "\n", -1)
+	lookup := make(map[string]string)
+	for key, val := range environment {
+		lookup[key] = val
+	}
+	interpolator := &Interpolator{Environment: lookup}
+
+	env := make(map[string]*string)
+	line := 1
+	for len(src) > 0 {
+		var current string
+		current, src = cutLine(src)
+		start := line
+		line++
+
+		current = strings.TrimSpace(current)
+		if current == "" || strings.HasPrefix(current, "#") {
+			continue
+		}
+		if strings.HasPrefix(current, "export ") {
+			current = strings.TrimSpace(current[len("export "):])
+		}
+
+		split := strings.SplitN(current, "=", 2)
+		key := strings.TrimSpace(split[0])
+		if len(split) == 1 {
+			// a bare key may still be followed by a comment
+			key = strings.TrimSpace(strings.SplitN(key, " #", 2)[0])
+		}
+		if !dotEnvKeyRegex.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", start, key)
+		}
+		if len(split) == 1 {
+			env[key] = nil
+			continue
+		}
+
+		value := strings.TrimLeft(split[1], " \t")
+		var err error
+		switch {
+		case strings.HasPrefix(value, "'"), strings.HasPrefix(value, `"`):
+			quote := value[0]
+			value = value[1:]
+			// quoted values may continue on the following lines
+			for !hasClosingQuote(value, quote) {
+				if src == "" {
+					return nil, fmt.Errorf("line %d: unterminated quoted value of %q", start, key)
+				}
+				var next string
+				next, src = cutLine(src)
+				line++
+				value += "\n" + next
+			}
+			end := closingQuote(value, quote)
+			rest := strings.TrimSpace(value[end+1:])
+			if rest != "" && !strings.HasPrefix(rest, "#") {
+				return nil, fmt.Errorf("line %d: unexpected characters after the quoted value of %q", start, key)
+			}
+			value = value[:end]
+			if quote == '"' {
+				value, err = interpolator.Interpolate(unescapeDoubleQuoted(value))
+			}
+		default:
+			if i := strings.Index(value, " #"); i != -1 {
+				value = value[:i]
+			}
+			value, err = interpolator.Interpolate(strings.TrimSpace(value))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", start, err)
+		}
+
+		env[key] = &value
+		lookup[key] = value
+	}
+	return env, nil
+}
+
+// Returns the first line of src and the remaining lines.
+func cutLine(src string) (string, string) {
+	if i := strings.IndexByte(src, '\n'); i != -1 {
+		return src[:i], src[i+1:]
+	}
+	return src, ""
+}
+
+func hasClosingQuote(value string, quote byte) bool {
+	return closingQuote(value, quote) != -1
+}
+
+// Returns the index of the closing quote, escaped double quotes are skipped.
+func closingQuote(value string, quote byte) int {
+	for i := 0; i < len(value); i++ {
+		if quote == '"' && value[i] == '\\' {
+			i++
+			continue
+		}
+		if value[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeDoubleQuoted(value string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+	return replacer.Replace(value)
+}
@@ -0,0 +1,53 @@
+package converter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sloppyio/sloppose/internal/test"
+	"github.com/sloppyio/sloppose/pkg/converter"
+)
+
+func TestNewComposeFileEnvFiles(t *testing.T) {
+	helper := test.NewHelper(t)
+	reader := &converter.ComposeReader{}
+	source, err := reader.ReadSource("testdata/envfile/compose.yml")
+	helper.Must(err)
+
+	cf, err := converter.NewComposeFileWithOptions([]*converter.ComposeSource{source}, &converter.ComposeOptions{ProjectName: "envfile"})
+	helper.Must(err)
+
+	expected := map[string]*string{
+		"HOST":        ToStrPtr("web.local"),
+		"LOG_LEVEL":   ToStrPtr("debug"),
+		"GREETING":    ToStrPtr("hello\tworld"),
+		"LITERAL":     ToStrPtr("${HOST} stays"),
+		"URL":         ToStrPtr("http://web.local:8080/#anchor"),
+		"PASSWORD":    ToStrPtr("override"),
+		"CERT":        ToStrPtr("-----BEGIN-----\nabc\n-----END-----"),
+		"EMPTY":       ToStrPtr(""),
+		"PASSTHROUGH": nil,
+	}
+	web := cf.Project.Services["web"]
+	if diff := cmp.Diff(web.Environment, expected); diff != "" {
+		t.Errorf("Result differs: (-got +want)\n%s", diff)
+	}
+	if web.EnvFiles != nil {
+		t.Errorf("Expected the env files to be resolved, got %v", web.EnvFiles)
+	}
+}
+
+func TestNewComposeFileInvalidEnvFile(t *testing.T) {
+	helper := test.NewHelper(t)
+	reader := &converter.ComposeReader{}
+	source, err := reader.ReadSource("testdata/envfile/invalid.yml")
+	helper.Must(err)
+
+	_, err = converter.NewComposeFileWithOptions([]*converter.ComposeSource{source}, &converter.ComposeOptions{ProjectName: "envfile"})
+	expected := `service "web": env file testdata/envfile/invalid.env: line 2: unterminated quoted value of "BAD"`
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,255 @@
+package converter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	sloppy "github.com/sloppyio/cli/pkg/api"
+)
+
+const (
+	healthCheckTypeHTTP = "HTTP"
+	healthCheckTypeTCP  = "TCP"
+)
+
+var ErrHealthCheckDisabled = errors.New("the healthcheck is disabled")
+
+// healthCheckTarget is the endpoint a healthcheck test probes.
+type healthCheckTarget struct {
+	Type string
+	Port int
+	// Path of HTTP checks, e.g. `/health`.
+	Path string
+}
+
+// Converts a compose healthcheck into a sloppy health check. The test has
+// to be a curl or wget request or a `nc -z` probe of the container itself.
+func (sf *SloppyFile) convertHealthCheck(healthcheck *ServiceHealthcheck) (*sloppy.HealthCheck, *healthCheckTarget, error) {
+	if healthcheck.Disable {
+		return nil, nil, ErrHealthCheckDisabled
+	}
+	target, err := parseHealthCheckTest(healthcheck.Test)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	check := &sloppy.HealthCheck{
+		Type:        sloppy.String(target.Type),
+		Interval:    durationSeconds(healthcheck.Interval),
+		Timeout:     durationSeconds(healthcheck.Timeout),
+		GracePeriod: durationSeconds(healthcheck.StartPeriod),
+	}
+	if target.Type == healthCheckTypeHTTP {
+		check.Path = sloppy.String(target.Path)
+	}
+	if healthcheck.Retries > 0 {
+		retries := healthcheck.Retries
+		check.MaxConsectiveFailure = &retries
+	}
+	return check, target, nil
+}
+
+// Returns the whole seconds of the duration rounded up, nil if not set.
+func durationSeconds(d time.Duration) *int {
+	if d <= 0 {
+		return nil
+	}
+	seconds := int(math.Ceil(d.Seconds()))
+	return &seconds
+}
+
+// Parses tests like `["CMD", "curl", "-f", "http://localhost:8080/health"]`,
+// `curl -f http://localhost/ || exit 1` or `nc -z localhost 5432`.
+func parseHealthCheckTest(test []string) (*healthCheckTarget, error) {
+	if len(test) == 0 {
+		return nil, errors.New("the healthcheck has no test")
+	}
+
+	var args []string
+	switch test[0] {
+	case "NONE":
+		return nil, ErrHealthCheckDisabled
+	case "CMD":
+		args = test[1:]
+	case "CMD-SHELL":
+		if len(test) != 2 {
+			return nil, fmt.Errorf("unsupported healthcheck test %q", strings.Join(test, " "))
+		}
+		var err error
+		args, err = SplitCommand(test[1])
+		if err != nil {
+			return nil, err
+		}
+		// the exit code of failing checks doesn't matter
+		if n := len(args); n >= 2 && args[n-2] == "||" && args[n-1] == "exit" {
+			args = args[:n-2]
+		} else if n >= 3 && args[n-3] == "||" && args[n-2] == "exit" {
+			args = args[:n-3]
+		}
+	default:
+		args = test
+	}
+	command := strings.Join(test, " ")
+	if len(args) == 0 {
+		return nil, fmt.Errorf("unsupported healthcheck test %q", command)
+	}
+	for _, arg := range args {
+		if strings.ContainsAny(arg, ";|&<>`") || strings.HasPrefix(arg, "$(") {
+			return nil, fmt.Errorf("the shell healthcheck test %q can't be converted", command)
+		}
+	}
+
+	switch args[0] {
+	case "curl", "wget":
+		return parseHTTPHealthCheck(args, command)
+	case "nc":
+		return parseTCPHealthCheck(args, command)
+	}
+	return nil, fmt.Errorf("the healthcheck test %q can't be converted, only HTTP requests with curl or wget and nc -z are supported", command)
+}
+
+// Flags of nc taking a value.
+var ncValueFlags = map[string]bool{"-i": true, "-p": true, "-q": true, "-s": true, "-w": true}
+
+// Finds the host and port probed by `nc -z <host> <port>`.
+func parseTCPHealthCheck(args []string, command string) (*healthCheckTarget, error) {
+	var scan bool
+	var operands []string
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case ncValueFlags[arg]:
+			i++
+		case strings.HasPrefix(arg, "-"):
+			if strings.Contains(arg[1:], "z") {
+				scan = true
+			}
+		default:
+			operands = append(operands, arg)
+		}
+	}
+	if !scan || len(operands) != 2 {
+		return nil, fmt.Errorf("the healthcheck test %q can't be converted, only port probes like nc -z localhost 5432 are supported", command)
+	}
+	if !isLocalHost(operands[0]) {
+		return nil, fmt.Errorf("the healthcheck test %q doesn't probe the container itself", command)
+	}
+	port, err := strconv.Atoi(operands[1])
+	if err != nil {
+		return nil, fmt.Errorf("the healthcheck test %q probes an invalid port", command)
+	}
+	return &healthCheckTarget{Type: healthCheckTypeTCP, Port: port}, nil
+}
+
+func isLocalHost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "0.0.0.0"
+}
+
+// Finds the URL requested by a curl or wget command.
+func parseHTTPHealthCheck(args []string, command string) (*healthCheckTarget, error) {
+	var rawURL string
+	for _, arg := range args[1:] {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		// URLs without scheme are recognized by their local host only,
+		// other arguments could be values of flags
+		if strings.Contains(arg, "://") {
+			rawURL = arg
+			break
+		}
+		if u, err := url.Parse("http://" + arg); err == nil && isLocalHost(u.Hostname()) {
+			rawURL = "http://" + arg
+			break
+		}
+	}
+	if rawURL == "" {
+		return nil, fmt.Errorf("the healthcheck test %q doesn't request an URL", command)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("the healthcheck test %q requests an invalid URL: %v", command, err)
+	}
+	if !isLocalHost(u.Hostname()) {
+		return nil, fmt.Errorf("the healthcheck test %q doesn't request the container itself", command)
+	}
+
+	target := &healthCheckTarget{Type: healthCheckTypeHTTP, Path: u.Path}
+	if target.Path == "" {
+		target.Path = "/"
+	}
+	if u.RawQuery != "" {
+		target.Path += "?" + u.RawQuery
+	}
+	switch {
+	case u.Port() != "":
+		target.Port, err = strconv.Atoi(u.Port())
+		if err != nil {
+			return nil, fmt.Errorf("the healthcheck test %q requests an invalid port", command)
+		}
+	case u.Scheme == "https":
+		target.Port = 443
+	default:
+		target.Port = 80
+	}
+	return target, nil
+}
+
+var ErrUnterminatedQuote = errors.New("unterminated quote in command")
+
+// SplitCommand splits a command string into its arguments like a posix
+// shell does: whitespace separates arguments, quotes and backslashes
+// escape. Variables and globs are kept as they are.
+func SplitCommand(command string) ([]string, error) {
+	var args []string
+	var arg bytes.Buffer
+	inArg := false
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		case c == '\\' && i+1 < len(command):
+			i++
+			arg.WriteByte(command[i])
+			inArg = true
+		case c == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end == -1 {
+				return nil, ErrUnterminatedQuote
+			}
+			arg.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		case c == '"':
+			i++
+			for ; i < len(command) && command[i] != '"'; i++ {
+				if command[i] == '\\' && i+1 < len(command) && strings.IndexByte("\"\\$`", command[i+1]) != -1 {
+					i++
+				}
+				arg.WriteByte(command[i])
+			}
+			if i == len(command) {
+				return nil, ErrUnterminatedQuote
+			}
+			inArg = true
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
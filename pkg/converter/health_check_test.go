@@ -0,0 +1,122 @@
+package converter_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	sloppy "github.com/sloppyio/cli/pkg/api"
+	"github.com/sloppyio/sloppose/pkg/converter"
+)
+
+func TestNewSloppyFileHealthChecks(t *testing.T) {
+	cases := map[string]struct {
+		healthcheck string
+		healthCheck *sloppy.HealthCheck
+		warnings    []string
+	}{
+		"curl": {
+			healthcheck: `
+      test: ["CMD", "curl", "-f", "http://localhost:8080/health?full=1"]
+      interval: 1m30s
+      timeout: 10s
+      start_period: 500ms
+      retries: 5`,
+			healthCheck: &sloppy.HealthCheck{
+				Type:                 sloppy.String("HTTP"),
+				Path:                 sloppy.String("/health?full=1"),
+				Interval:             sloppy.Int(90),
+				Timeout:              sloppy.Int(10),
+				GracePeriod:          sloppy.Int(1),
+				MaxConsectiveFailure: sloppy.Int(5),
+			},
+		},
+		"curl without scheme": {
+			healthcheck: `
+      test: curl -sf -m 5 localhost:8080 || exit 1`,
+			healthCheck: &sloppy.HealthCheck{Type: sloppy.String("HTTP"), Path: sloppy.String("/")},
+		},
+		"wget": {
+			healthcheck: `
+      test: ["CMD-SHELL", "wget -qO- http://0.0.0.0:8080/status || exit"]`,
+			healthCheck: &sloppy.HealthCheck{Type: sloppy.String("HTTP"), Path: sloppy.String("/status")},
+		},
+		"nc": {
+			healthcheck: `
+      test: ["CMD", "nc", "-z", "-w", "2", "127.0.0.1", "8080"]
+      interval: 10s`,
+			healthCheck: &sloppy.HealthCheck{Type: sloppy.String("TCP"), Interval: sloppy.Int(10)},
+		},
+		"other port": {
+			healthcheck: `
+      test: nc -zv localhost 9090`,
+			healthCheck: &sloppy.HealthCheck{Type: sloppy.String("TCP")},
+			warnings:    []string{`Service "web": the healthcheck probes port 9090, but sloppy.io checks the app port 8080.`},
+		},
+		"disabled": {
+			healthcheck: `
+      test: ["NONE"]`,
+		},
+		"arbitrary command": {
+			healthcheck: `
+      test: ["CMD", "pg_isready", "-U", "postgres"]`,
+			warnings: []string{`Service "web": the healthcheck test "CMD pg_isready -U postgres" can't be converted, ` +
+				`only HTTP requests with curl or wget and nc -z are supported. Skipping the healthcheck.`},
+		},
+		"shell script": {
+			healthcheck: `
+      test: curl -f http://localhost:8080/ && test -f /tmp/ready`,
+			warnings: []string{`Service "web": the shell healthcheck test "CMD-SHELL curl -f http://localhost:8080/ && test -f /tmp/ready" ` +
+				`can't be converted. Skipping the healthcheck.`},
+		},
+		"remote host": {
+			healthcheck: `
+      test: curl -f http://example.com/`,
+			warnings: []string{`Service "web": the healthcheck test "CMD-SHELL curl -f http://example.com/" ` +
+				`doesn't request the container itself. Skipping the healthcheck.`},
+		},
+		"nc without scan": {
+			healthcheck: `
+      test: nc localhost 8080`,
+			warnings: []string{`Service "web": the healthcheck test "CMD-SHELL nc localhost 8080" can't be converted, ` +
+				`only port probes like nc -z localhost 5432 are supported. Skipping the healthcheck.`},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := []byte(`services:
+  web:
+    image: nginx
+    ports:
+    - "8080:8080"
+    healthcheck:` + c.healthcheck + "\n")
+			cf, err := converter.NewComposeFile(buf, "healthchecks")
+			if err != nil {
+				t.Fatal(err)
+			}
+			sf, err := converter.NewSloppyFile(cf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var healthChecks []*sloppy.HealthCheck
+			if c.healthCheck != nil {
+				healthChecks = []*sloppy.HealthCheck{c.healthCheck}
+			}
+			if diff := cmp.Diff(sf.Services["apps"]["web"].HealthChecks, healthChecks); diff != "" {
+				t.Errorf("Health checks differ: (-got +want)\n%s", diff)
+			}
+			if diff := cmp.Diff(sf.Warnings, c.warnings); diff != "" {
+				t.Errorf("Warnings differ: (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSplitCommand_UnterminatedQuote(t *testing.T) {
+	_, err := converter.SplitCommand(`echo "hello`)
+	if err != converter.ErrUnterminatedQuote {
+		t.Errorf("Expected %v, got %v", converter.ErrUnterminatedQuote, err)
+	}
+}
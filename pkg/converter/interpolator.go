@@ -0,0 +1,223 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Interpolator substitutes variables within compose file values
+// the same way docker-compose does:
+//
+//	$VAR, ${VAR}          value of VAR, empty if unset
+//	${VAR:-default}       default if VAR is unset or empty
+//	${VAR-default}        default if VAR is unset
+//	${VAR:?err}           fails with err if VAR is unset or empty
+//	${VAR?err}            fails with err if VAR is unset
+//	${VAR:+replacement}   replacement if VAR is set and not empty
+//	${VAR+replacement}    replacement if VAR is set
+//	$$                    a literal $
+//
+// Defaults and replacements may contain variables themselves.
+type Interpolator struct {
+	Environment map[string]string
+
+	unset map[string]bool
+}
+
+// InterpolateTree substitutes variables of all string values within the
+// given yaml tree. Mapping keys are left untouched.
+func (i *Interpolator) InterpolateTree(tree map[string]interface{}) (map[string]interface{}, error) {
+	out, err := i.interpolateValue("", tree)
+	if err != nil {
+		return nil, err
+	}
+	return out.(map[string]interface{}), nil
+}
+
+func (i *Interpolator) interpolateValue(path string, in interface{}) (interface{}, error) {
+	switch in.(type) {
+	case string:
+		out, err := i.Interpolate(in.(string))
+		if err != nil {
+			return nil, fmt.Errorf("invalid interpolation in %q: %v", path, err)
+		}
+		return out, nil
+	case map[string]interface{}:
+		out := make(map[string]interface{})
+		for key, val := range in.(map[string]interface{}) {
+			p := key
+			if path != "" {
+				p = path + "." + key
+			}
+			v, err := i.interpolateValue(p, val)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = v
+		}
+		return out, nil
+	case []interface{}:
+		var out []interface{}
+		for idx, val := range in.([]interface{}) {
+			v, err := i.interpolateValue(fmt.Sprintf("%s[%d]", path, idx), val)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	}
+	return in, nil
+}
+
+// Interpolate substitutes all variables within the given string.
+func (i *Interpolator) Interpolate(in string) (string, error) {
+	var out bytes.Buffer
+	for pos := 0; pos < len(in); {
+		if in[pos] != '$' {
+			out.WriteByte(in[pos])
+			pos++
+			continue
+		}
+		if pos+1 == len(in) {
+			return "", fmt.Errorf("invalid interpolation format: %q", in)
+		}
+
+		switch next := in[pos+1]; {
+		case next == '$':
+			out.WriteByte('$')
+			pos += 2
+		case next == '{':
+			end := i.closingBrace(in, pos+2)
+			if end == -1 {
+				return "", fmt.Errorf("invalid interpolation format: %q", in)
+			}
+			val, err := i.substitute(in[pos+2 : end])
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(val)
+			pos = end + 1
+		case isVariableStart(next):
+			end := pos + 1
+			for end < len(in) && isVariableChar(in[end]) {
+				end++
+			}
+			out.WriteString(i.lookup(in[pos+1 : end]))
+			pos = end
+		default:
+			return "", fmt.Errorf("invalid interpolation format: %q", in)
+		}
+	}
+	return out.String(), nil
+}
+
+// UnsetVariables returns the sorted names of all variables which were
+// referenced without a default but are not set.
+func (i *Interpolator) UnsetVariables() []string {
+	var names []string
+	for name := range i.unset {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Evaluates the inner part of a braced expression like `VAR:-default`.
+func (i *Interpolator) substitute(expr string) (string, error) {
+	n := 0
+	for n < len(expr) && isVariableChar(expr[n]) {
+		n++
+	}
+	name, op := expr[:n], expr[n:]
+	if name == "" || !isVariableStart(name[0]) {
+		return "", fmt.Errorf("invalid variable name in ${%s}", expr)
+	}
+	if op == "" {
+		return i.lookup(name), nil
+	}
+
+	value, set := i.Environment[name]
+	for _, operator := range []string{":-", ":?", ":+", "-", "?", "+"} {
+		if !strings.HasPrefix(op, operator) {
+			continue
+		}
+		arg := op[len(operator):]
+		nonEmpty := set && value != ""
+		if strings.HasPrefix(operator, ":") {
+			set = nonEmpty
+		}
+
+		switch operator[len(operator)-1] {
+		case '-':
+			if !set {
+				return i.Interpolate(arg)
+			}
+			return value, nil
+		case '?':
+			if !set {
+				msg, err := i.Interpolate(arg)
+				if err != nil {
+					return "", err
+				}
+				return "", fmt.Errorf("required variable %q is missing a value: %s", name, msg)
+			}
+			return value, nil
+		case '+':
+			if set {
+				return i.Interpolate(arg)
+			}
+			return "", nil
+		}
+	}
+	return "", fmt.Errorf("invalid interpolation format: ${%s}", expr)
+}
+
+// Returns an interpolator for the given environment, variables it
+// doesn't find are reported by both interpolators.
+func (i *Interpolator) child(environment map[string]string) *Interpolator {
+	if i.unset == nil {
+		i.unset = make(map[string]bool)
+	}
+	return &Interpolator{Environment: environment, unset: i.unset}
+}
+
+func (i *Interpolator) lookup(name string) string {
+	value, ok := i.Environment[name]
+	if !ok {
+		if i.unset == nil {
+			i.unset = make(map[string]bool)
+		}
+		i.unset[name] = true
+	}
+	return value
+}
+
+// Returns the index of the brace closing the expression starting
+// at pos, nested `${...}` expressions are skipped.
+func (i *Interpolator) closingBrace(in string, pos int) int {
+	depth := 1
+	for ; pos < len(in); pos++ {
+		switch {
+		case in[pos] == '$' && pos+1 < len(in) && in[pos+1] == '{':
+			depth++
+			pos++
+		case in[pos] == '}':
+			depth--
+			if depth == 0 {
+				return pos
+			}
+		}
+	}
+	return -1
+}
+
+func isVariableStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isVariableChar(c byte) bool {
+	return isVariableStart(c) || (c >= '0' && c <= '9')
+}
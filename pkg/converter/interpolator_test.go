@@ -0,0 +1,147 @@
+package converter_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sloppyio/sloppose/internal/test"
+	"github.com/sloppyio/sloppose/pkg/config"
+	"github.com/sloppyio/sloppose/pkg/converter"
+)
+
+func TestInterpolator_Interpolate(t *testing.T) {
+	cases := map[string]struct {
+		expected    string
+		shouldError bool
+	}{
+		"plain":                       {"plain", false},
+		"$FOO":                        {"foo", false},
+		"${FOO}-${BAR}":               {"foo-", false},
+		"$$FOO":                       {"$FOO", false},
+		"${UNSET:-default}":           {"default", false},
+		"${EMPTY:-default}":           {"default", false},
+		"${EMPTY-default}":            {"", false},
+		"${UNSET-default}":            {"default", false},
+		"${UNSET:-${FOO}}":            {"foo", false},
+		"${FOO:+replaced}":            {"replaced", false},
+		"${EMPTY:+replaced}":          {"", false},
+		"${EMPTY+replaced}":           {"replaced", false},
+		"${FOO:?required}":            {"foo", false},
+		"${EMPTY?required}":           {"", false},
+		"${EMPTY:?required}":          {"", true},
+		"${UNSET?required}":           {"", true},
+		"${FOO":                       {"", true},
+		"${}":                         {"", true},
+		"${1FOO}":                     {"", true},
+		"trailing $":                  {"", true},
+		"${FOO:=assign}":              {"", true},
+		"http://${FOO}:${PORT:-80}/x": {"http://foo:80/x", false},
+	}
+
+	for in, c := range cases {
+		interpolator := &converter.Interpolator{
+			Environment: map[string]string{"FOO": "foo", "EMPTY": ""},
+		}
+		out, err := interpolator.Interpolate(in)
+		if c.shouldError {
+			if err == nil {
+				t.Errorf("Expected an error for %q, got %q.", in, out)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %v", in, err)
+		} else if out != c.expected {
+			t.Errorf("Expected %q for %q, got %q.", c.expected, in, out)
+		}
+	}
+}
+
+func TestInterpolator_UnsetVariables(t *testing.T) {
+	interpolator := &converter.Interpolator{}
+	_, err := interpolator.Interpolate("${B} $A ${C:-c} ${A}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(interpolator.UnsetVariables(), []string{"A", "B"}); diff != "" {
+		t.Errorf("Result differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestNewComposeFileInterpolation(t *testing.T) {
+	helper := test.NewHelper(t)
+	os.Setenv("GREETING", "moin")
+	defer os.Unsetenv("GREETING")
+
+	reader := &converter.ComposeReader{}
+	source, err := reader.ReadSource("testdata/interpolation/docker-compose.yml")
+	helper.Must(err)
+	cf, err := converter.NewComposeFileWithOptions([]*converter.ComposeSource{source}, &converter.ComposeOptions{ProjectName: "interpolation"})
+	helper.Must(err)
+
+	api := cf.Project.Services["api"]
+	if api.Image != "myorg/api:1.2.3" {
+		t.Errorf("Expected the image tag from the .env file, got %q.", api.Image)
+	}
+	if diff := cmp.Diff(api.Command, config.ShellCommand{Shell: "echo $HOME moin "}); diff != "" {
+		t.Errorf("Result differs: (-got +want)\n%s", diff)
+	}
+	expectedEnv := map[string]*string{
+		"REGISTRY": ToStrPtr("registry.sloppy.io"),
+		"DEBUG":    ToStrPtr(""),
+	}
+	if diff := cmp.Diff(api.Environment, expectedEnv); diff != "" {
+		t.Errorf("Result differs: (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(cf.UnsetVariables, []string{"MISSING"}); diff != "" {
+		t.Errorf("Result differs: (-got +want)\n%s", diff)
+	}
+}
+
+func TestComposeLoader_InterpolatedTypes(t *testing.T) {
+	service := `services:
+  web:
+    image: nginx:${TAG}
+    read_only: ${READ_ONLY}
+    privileged: ${PRIVILEGED:-no}
+    stop_grace_period: ${REPLICAS}s
+    environment:
+      REPLICAS: ${REPLICAS}
+    healthcheck:
+      retries: ${RETRIES:-3}
+    deploy:
+      replicas: ${REPLICAS}
+`
+	expected := &config.Service{
+		Image:           "nginx:1",
+		ReadOnly:        true,
+		StopGracePeriod: "2s",
+		Environment:     config.MappingWithEquals{"REPLICAS": ToStrPtr("2")},
+		Healthcheck:     &config.Healthcheck{Retries: 3},
+		Deploy:          &config.Deployment{Replicas: 2},
+	}
+
+	for name, header := range map[string]string{"version 3": "version: \"3.8\"\n", "specification": ""} {
+		t.Run(name, func(t *testing.T) {
+			loader := &converter.ComposeLoader{Interpolator: &converter.Interpolator{
+				Environment: map[string]string{"TAG": "1", "READ_ONLY": "true", "REPLICAS": "2"},
+			}}
+			doc, _, err := loader.Load(&converter.ComposeSource{Content: []byte(header + service)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(doc.Services["web"], expected); diff != "" {
+				t.Errorf("Result differs: (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewComposeFileInterpolationRequired(t *testing.T) {
+	_, err := converter.NewComposeFile([]byte("version: '3'\nservices:\n  a:\n    image: ${UNSET_IMAGE:?an image is required}\n"), "")
+	if err == nil {
+		t.Errorf("Expected an error due to a missing required variable.")
+	}
+}
@@ -3,9 +3,9 @@ package converter
 import (
 	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
-
-	sloppy "github.com/sloppyio/cli/pkg/api"
 )
 
 const (
@@ -16,6 +16,7 @@ const (
 
 var hostPortRegex *regexp.Regexp = regexp.MustCompile(hostPortPattern)
 var schemeRegex *regexp.Regexp = regexp.MustCompile(schemePattern)
+var portRefRegex *regexp.Regexp = regexp.MustCompile(`(?:^|[^a-z0-9._-])([a-z][a-z0-9._-]*):([0-9]{2,5})`)
 
 type DependencyError struct {
 	errStr string
@@ -28,7 +29,7 @@ type Linker struct {
 type link struct {
 	app     *SloppyApp
 	fqdn    string
-	ports   []*sloppy.PortMap
+	ports   []int
 	appName string
 }
 
@@ -51,12 +52,19 @@ func (l *Linker) GetByApp(name string) *link {
 
 func (l *Linker) Resolve(cf *ComposeFile, sf *SloppyFile) error {
 	const at = "@"
-	l.buildLinks(sf)
+	l.buildLinks(cf, sf)
 
 	// resolve possible connections
 	for _, link := range l.links {
-		for key, val := range link.app.App.EnvVars {
+		service := cf.Project.Services[link.appName]
+		var keys []string
+		for key := range link.app.App.EnvVars {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
 			app := link.app.App
+			val := app.EnvVars[key]
 			var match string
 			if strings.Contains(val, at) { // handling admin:pass@urls
 				match = l.FindServiceString(key, strings.Split(val, at)[1])
@@ -67,12 +75,14 @@ func (l *Linker) Resolve(cf *ComposeFile, sf *SloppyFile) error {
 				continue
 			}
 
-			targetLink := l.GetByApp(match)
+			// aliases of `links` refer to the linked service
+			targetLink := l.GetByApp(service.linkedService(match))
 
 			if targetLink == nil {
 				fmt.Printf("Couldn't find %q as linkable app. Assuming %q is an external service.\n", match, val)
 				continue
 			}
+			l.checkPort(sf, targetLink, match, val)
 
 			var targetVar string
 			schemeIdx := strings.Index(app.EnvVars[key], "://")
@@ -106,11 +116,18 @@ func (l *Linker) Resolve(cf *ComposeFile, sf *SloppyFile) error {
 		}
 
 		// also considering DependsOn from compose
-		if conf, ok := cf.ServiceConfigs[link.appName]; ok && conf.DependsOn != nil {
-			depends := conf.DependsOn.([]interface{})
-			for _, d := range depends {
-				dep := d.(string)
+		if service != nil && service.DependsOn != nil {
+			var depends []string
+			for dep := range service.DependsOn {
+				depends = append(depends, dep)
+			}
+			sort.Strings(depends)
+			for _, dep := range depends {
 				t := l.GetByApp(dep)
+				if t == nil && !service.DependsOn[dep].Required {
+					// optional dependencies may be disabled by profiles
+					continue
+				}
 				if t == nil {
 					return newDependencyError(`Couldn't find related service %q declared in "depends_on"`, dep)
 				}
@@ -122,6 +139,32 @@ func (l *Linker) Resolve(cf *ComposeFile, sf *SloppyFile) error {
 	return nil
 }
 
+// References returns the names of the services the environment values
+// of the given service refer to, the same way Resolve finds them.
+func (l *Linker) References(service *ComposeService) []string {
+	const at = "@"
+	seen := make(map[string]bool)
+	for key, val := range service.Environment {
+		if val == nil {
+			continue
+		}
+		value := *val
+		if strings.Contains(value, at) { // handling admin:pass@urls
+			value = strings.Split(value, at)[1]
+		}
+		if match := l.FindServiceString(key, value); match != "" {
+			seen[service.linkedService(match)] = true
+		}
+	}
+
+	var names []string
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (l *Linker) appendDependency(app *SloppyApp, fqdn string) []string {
 	s := l.formatDependency(fqdn)
 	if len(app.App.Dependencies) > 0 {
@@ -137,14 +180,14 @@ func (l *Linker) appendDependency(app *SloppyApp, fqdn string) []string {
 	)
 }
 
-func (l *Linker) buildLinks(sf *SloppyFile) {
+func (l *Linker) buildLinks(cf *ComposeFile, sf *SloppyFile) {
 	for serviceName, apps := range sf.Services {
 		for appName, app := range apps {
 			l.links = append(
 				l.links, &link{
 					app:     app,
 					fqdn:    fmt.Sprintf(fqdnTemplate, appName, serviceName, sf.Project),
-					ports:   app.PortMappings,
+					ports:   app.containerPorts(),
 					appName: appName,
 				},
 			)
@@ -152,6 +195,40 @@ func (l *Linker) buildLinks(sf *SloppyFile) {
 	}
 }
 
+// Reports references to ports the linked app doesn't listen on, e.g. the
+// published port instead of the container port.
+func (l *Linker) checkPort(sf *SloppyFile, target *link, match, val string) {
+	var subMatch []string
+	for _, m := range portRefRegex.FindAllStringSubmatch(val, -1) {
+		if m[1] == match {
+			subMatch = m
+			break
+		}
+	}
+	if subMatch == nil {
+		return
+	}
+	port, _ := strconv.Atoi(subMatch[2])
+	for _, p := range target.ports {
+		if p == port {
+			return
+		}
+	}
+
+	var ports []string
+	for _, p := range target.ports {
+		ports = append(ports, strconv.Itoa(p))
+	}
+	if len(ports) == 0 {
+		sf.Warnings = append(sf.Warnings, fmt.Sprintf(
+			"%q refers to the port %d of %q, which has no container ports.", val, port, match))
+		return
+	}
+	sf.Warnings = append(sf.Warnings, fmt.Sprintf(
+		"%q refers to the port %d of %q, which only listens on the ports %s.",
+		val, port, match, strings.Join(ports, ", ")))
+}
+
 // Searches for services in environment variable values.
 // Primary match would be a <host:port> one.
 // To also support service linking without a port the
@@ -81,7 +81,6 @@ func TestLinker_Resolve(t *testing.T) {
 }
 
 func TestLinker_ResolveUsrPwd(t *testing.T) {
-	linker := &converter.Linker{}
 	name := "sloppy-test"
 
 	expected := &converter.SloppyFile{
@@ -111,18 +110,117 @@ func TestLinker_ResolveUsrPwd(t *testing.T) {
 				},
 			},
 		},
+		Warnings: []string{
+			`"https://admin:pass@b:443" refers to the port 443 of "b", which only listens on the ports 4443.`,
+			`"b:443" refers to the port 443 of "b", which only listens on the ports 4443.`,
+		},
 	}
 
-	cf, sf := loadSloppyFile("/testdata/fixture_linker1.yml")
-	err := linker.Resolve(cf, sf)
+	_, sf := loadSloppyFile("/testdata/fixture_linker1.yml")
+
+	if diff := cmp.Diff(sf, expected); diff != "" {
+		t.Errorf("Result differs: (-got +want)\n%s", diff)
+	}
+
+}
+
+func TestLinker_ResolvePorts(t *testing.T) {
+	cases := map[string]struct {
+		web      string
+		expected []string
+	}{
+		"container port": {
+			web: `
+    ports:
+    - "8080:80"`,
+			expected: []string{`"http://web:8080" refers to the port 8080 of "web", which only listens on the ports 80.`},
+		},
+		"dropped port": {
+			web: `
+    ports:
+    - "80"
+    - "8080"`,
+			expected: []string{`"http://web:8080" refers to the port 8080 of "web", which only listens on the ports 80.`},
+		},
+		"port of the settings": {
+			web: `
+    expose:
+    - "9000"
+    x-sloppy:
+      port: 8080`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := []byte(`version: "3.7"
+services:
+  client:
+    image: client
+    environment:
+      WEB_URL: http://web:8080
+  web:
+    image: web` + c.web + "\n")
+			cf, err := converter.NewComposeFile(buf, "ports")
+			if err != nil {
+				t.Fatal(err)
+			}
+			sf, err := converter.NewSloppyFile(cf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			converted := len(sf.Warnings)
+
+			linker := &converter.Linker{}
+			if err := linker.Resolve(cf, sf); err != nil {
+				t.Fatal(err)
+			}
+			var warnings []string
+			if len(sf.Warnings) > converted {
+				warnings = sf.Warnings[converted:]
+			}
+			if diff := cmp.Diff(warnings, c.expected); diff != "" {
+				t.Errorf("Result differs: (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLinker_ResolveLinkAlias(t *testing.T) {
+	buf := []byte(`version: "3.7"
+services:
+  web:
+    image: web
+    links:
+    - db:database
+    environment:
+      DATABASE_URL: postgres://database:5432/web
+  db:
+    image: postgres
+    expose:
+    - "5432"
+`)
+	cf, err := converter.NewComposeFile(buf, "links")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf, err := converter.NewSloppyFile(cf)
 	if err != nil {
 		t.Fatal(err)
 	}
+	linker := &converter.Linker{}
+	if err := linker.Resolve(cf, sf); err != nil {
+		t.Fatal(err)
+	}
 
-	if diff := cmp.Diff(sf, expected); diff != "" {
+	web := sf.Services["apps"]["web"]
+	if diff := cmp.Diff(web.App.EnvVars["DATABASE_URL"], "postgres://db.apps.links:5432/web"); diff != "" {
+		t.Errorf("Result differs: (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(web.App.Dependencies, []string{"../apps/db"}); diff != "" {
 		t.Errorf("Result differs: (-got +want)\n%s", diff)
 	}
-
 }
 
 func ToIntPtr(i int) *int {
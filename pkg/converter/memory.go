@@ -0,0 +1,87 @@
+package converter
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const mebibyte = 1024 * 1024
+
+// MemorySizes are the memory sizes in MB sloppy.io supports, the smallest
+// is the minimum of every app.
+var MemorySizes = []int{64, 128, 256, 512, 1024, 2048, 4096, 8192}
+
+var byteSizeRegex = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)\s*(b|[kmgt]i?b?)?$`)
+
+// Parses docker byte sizes like `1073741824`, `512m`, `512MiB`, `1.5G` or
+// `64kb` into bytes. All units are binary like docker treats them.
+func parseByteSize(size string) (float64, error) {
+	match := byteSizeRegex.FindStringSubmatch(strings.TrimSpace(size))
+	if match == nil {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+	exponent := 0
+	if match[2] != "" {
+		exponent = strings.Index("bkmgt", strings.ToLower(match[2][:1]))
+	}
+	return value * math.Pow(1024, float64(exponent)), nil
+}
+
+// Returns the memory of the app in MB. The limit is used, the reservation
+// if there is no limit, otherwise the given default. Values below the
+// minimum of sloppy.io are raised and if round is set, values are rounded
+// up to the next supported size. Both is reported.
+func (sf *SloppyFile) convertMemory(name string, service *ComposeService, defaultMemory string, round bool) (*int, error) {
+	size := service.MemoryLimit
+	if size == "" {
+		size = service.MemoryReservation
+	}
+	if size == "" {
+		size = defaultMemory
+	}
+	if size == "" {
+		return nil, nil
+	}
+
+	bytes, err := parseByteSize(size)
+	if err != nil {
+		return nil, fmt.Errorf("service %q: invalid memory %q", name, size)
+	}
+	mem := int(math.Ceil(bytes / mebibyte))
+
+	if mem < MemorySizes[0] {
+		sf.Warnings = append(sf.Warnings, fmt.Sprintf(
+			"Service %q: the memory of %s is raised to the minimum of %d MB.", name, size, MemorySizes[0]))
+		mem = MemorySizes[0]
+	}
+	if round {
+		rounded, err := roundMemory(mem)
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %v", name, err)
+		}
+		if rounded != mem {
+			sf.Warnings = append(sf.Warnings, fmt.Sprintf(
+				"Service %q: the memory of %d MB is rounded up to %d MB.", name, mem, rounded))
+			mem = rounded
+		}
+	}
+	return &mem, nil
+}
+
+// Returns the smallest supported memory size of at least mem MB.
+func roundMemory(mem int) (int, error) {
+	for _, size := range MemorySizes {
+		if size >= mem {
+			return size, nil
+		}
+	}
+	return 0, fmt.Errorf("the memory of %d MB exceeds the largest size sloppy.io supports, %d MB",
+		mem, MemorySizes[len(MemorySizes)-1])
+}
@@ -0,0 +1,139 @@
+package converter_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sloppyio/sloppose/pkg/converter"
+)
+
+func TestNewSloppyFileMemory(t *testing.T) {
+	buf := []byte(`services:
+  bytes:
+    image: nginx
+    deploy:
+      resources:
+        limits:
+          memory: "536870912"
+  fraction:
+    image: nginx
+    deploy:
+      resources:
+        limits:
+          memory: 1.5G
+  mebibytes:
+    image: nginx
+    deploy:
+      resources:
+        limits:
+          memory: 512MiB
+        reservations:
+          memory: 128m
+  reservation:
+    image: nginx
+    deploy:
+      resources:
+        reservations:
+          memory: 300mb
+  small:
+    image: nginx
+    deploy:
+      resources:
+        limits:
+          memory: 1024k
+  unset:
+    image: nginx
+`)
+	for _, tc := range []struct {
+		name     string
+		options  *converter.SloppyOptions
+		memory   map[string]int
+		warnings []string
+	}{
+		{
+			name:    "exact",
+			options: &converter.SloppyOptions{},
+			memory: map[string]int{
+				"bytes": 512, "fraction": 1536, "mebibytes": 512, "reservation": 300, "small": 64,
+			},
+			warnings: []string{
+				`Service "small": the memory of 1024k is raised to the minimum of 64 MB.`,
+			},
+		},
+		{
+			name:    "rounded with default",
+			options: &converter.SloppyOptions{DefaultMemory: "100M", RoundMemory: true},
+			memory: map[string]int{
+				"bytes": 512, "fraction": 2048, "mebibytes": 512, "reservation": 512, "small": 64, "unset": 128,
+			},
+			warnings: []string{
+				`Service "fraction": the memory of 1536 MB is rounded up to 2048 MB.`,
+				`Service "reservation": the memory of 300 MB is rounded up to 512 MB.`,
+				`Service "small": the memory of 1024k is raised to the minimum of 64 MB.`,
+				`Service "unset": the memory of 100 MB is rounded up to 128 MB.`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cf, err := converter.NewComposeFile(buf, "memory")
+			if err != nil {
+				t.Fatal(err)
+			}
+			sf, err := converter.NewSloppyFileWithOptions(cf, tc.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			memory := make(map[string]int)
+			for name, app := range sf.Services["apps"] {
+				if app.Memory != nil {
+					memory[name] = *app.Memory
+				}
+			}
+			if diff := cmp.Diff(memory, tc.memory); diff != "" {
+				t.Errorf("Memory differs: (-got +want)\n%s", diff)
+			}
+			if diff := cmp.Diff(sf.Warnings, tc.warnings); diff != "" {
+				t.Errorf("Warnings differ: (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewSloppyFileInvalidMemory(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		memory  string
+		options *converter.SloppyOptions
+		err     string
+	}{
+		{"invalid", "lots", &converter.SloppyOptions{}, `service "web": invalid memory "lots"`},
+		{"unit bb", "1bb", &converter.SloppyOptions{}, `service "web": invalid memory "1bb"`},
+		{"unit ib", "512ib", &converter.SloppyOptions{}, `service "web": invalid memory "512ib"`},
+		{"too large", "16G", &converter.SloppyOptions{RoundMemory: true},
+			`service "web": the memory of 16384 MB exceeds the largest size sloppy.io supports, 8192 MB`},
+		{"invalid default", "", &converter.SloppyOptions{DefaultMemory: "1x"}, `invalid default memory "1x"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := []byte(`services:
+  web:
+    image: nginx
+`)
+			if tc.memory != "" {
+				buf = append(buf, []byte(`    deploy:
+      resources:
+        limits:
+          memory: `+tc.memory+"\n")...)
+			}
+			cf, err := converter.NewComposeFile(buf, "memory")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = converter.NewSloppyFileWithOptions(cf, tc.options)
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("Expected error %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package converter
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/sloppyio/sloppose/pkg/config"
+)
+
+const portProtocolTCP = "tcp"
+
+// ServicePort is a single port of a service with parsed port numbers.
+type ServicePort struct {
+	HostIP string
+	// Published is the host port, 0 if the port isn't published.
+	Published int
+	Target    int
+	// Protocol is always set, `tcp` by default.
+	Protocol string
+}
+
+// ParsePort parses the short port syntax of compose files, e.g.
+// `127.0.0.1:8080:80/tcp`.
+func ParsePort(spec string) (*ServicePort, error) {
+	entry, err := config.ParsePortShortSyntax(spec)
+	if err != nil {
+		return nil, err
+	}
+	return NewServicePort(entry)
+}
+
+// NewServicePort validates the given port of the long syntax. Ranges and
+// protocols other than TCP are rejected, sloppy.io can't represent them.
+func NewServicePort(entry config.ServicePortConfig) (*ServicePort, error) {
+	spec := formatPortConfig(entry)
+	port := &ServicePort{
+		HostIP:   entry.HostIP,
+		Protocol: strings.ToLower(entry.Protocol),
+	}
+	if port.Protocol == "" {
+		port.Protocol = portProtocolTCP
+	}
+
+	switch port.Protocol {
+	case portProtocolTCP:
+	case "udp", "sctp":
+		return nil, fmt.Errorf("%s ports are not supported by sloppy.io: %q", strings.ToUpper(port.Protocol), spec)
+	default:
+		return nil, fmt.Errorf("invalid protocol of port %q", spec)
+	}
+	if strings.Contains(entry.Target, "-") || strings.Contains(entry.Published, "-") {
+		return nil, fmt.Errorf("port ranges are not supported: %q", spec)
+	}
+	if port.HostIP != "" && net.ParseIP(port.HostIP) == nil {
+		return nil, fmt.Errorf("invalid host IP of port %q", spec)
+	}
+
+	var err error
+	port.Target, err = parsePortNumber(entry.Target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", spec)
+	}
+	if entry.Published != "" {
+		port.Published, err = parsePortNumber(entry.Published)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q", spec)
+		}
+	}
+	return port, nil
+}
+
+// String returns the port in the short syntax.
+func (p *ServicePort) String() string {
+	var published string
+	if p.Published != 0 {
+		published = strconv.Itoa(p.Published)
+	}
+	return formatPortConfig(config.ServicePortConfig{
+		HostIP:    p.HostIP,
+		Published: published,
+		Target:    strconv.Itoa(p.Target),
+		Protocol:  p.Protocol,
+	})
+}
+
+func parsePortNumber(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if port < 1 || port > 65535 {
+		return 0, fmt.Errorf("port %d out of range", port)
+	}
+	return port, nil
+}
+
+// Returns the short syntax of the port, used to refer to it in errors.
+func formatPortConfig(entry config.ServicePortConfig) string {
+	spec := entry.Target
+	if entry.Published != "" || entry.HostIP != "" {
+		spec = entry.Published + ":" + spec
+	}
+	if entry.HostIP != "" {
+		hostIP := entry.HostIP
+		if strings.Contains(hostIP, ":") {
+			hostIP = "[" + hostIP + "]"
+		}
+		spec = hostIP + ":" + spec
+	}
+	if entry.Protocol != "" {
+		spec += "/" + entry.Protocol
+	}
+	return spec
+}
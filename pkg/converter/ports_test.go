@@ -0,0 +1,143 @@
+package converter_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sloppyio/sloppose/pkg/converter"
+)
+
+func TestParsePort(t *testing.T) {
+	cases := map[string]struct {
+		expected *converter.ServicePort
+		err      string
+	}{
+		"80": {
+			expected: &converter.ServicePort{Target: 80, Protocol: "tcp"},
+		},
+		"8080:80": {
+			expected: &converter.ServicePort{Published: 8080, Target: 80, Protocol: "tcp"},
+		},
+		"127.0.0.1:8080:80": {
+			expected: &converter.ServicePort{HostIP: "127.0.0.1", Published: 8080, Target: 80, Protocol: "tcp"},
+		},
+		"127.0.0.1::80": {
+			expected: &converter.ServicePort{HostIP: "127.0.0.1", Target: 80, Protocol: "tcp"},
+		},
+		"[::1]:8080:80/TCP": {
+			expected: &converter.ServicePort{HostIP: "::1", Published: 8080, Target: 80, Protocol: "tcp"},
+		},
+		"80/udp": {
+			err: `UDP ports are not supported by sloppy.io: "80/udp"`,
+		},
+		"8080:80/sctp": {
+			err: `SCTP ports are not supported by sloppy.io: "8080:80/sctp"`,
+		},
+		"80/http": {
+			err: `invalid protocol of port "80/http"`,
+		},
+		"8000-8010": {
+			err: `port ranges are not supported: "8000-8010"`,
+		},
+		"80-90:8080-8090": {
+			err: `port ranges are not supported: "80-90:8080-8090"`,
+		},
+		"80:8O": {
+			err: `invalid port "80:8O"`,
+		},
+		"70000": {
+			err: `invalid port "70000"`,
+		},
+		"localhost:8080:80": {
+			err: `invalid host IP of port "localhost:8080:80"`,
+		},
+	}
+
+	for spec, c := range cases {
+		t.Run(spec, func(t *testing.T) {
+			port, err := converter.ParsePort(spec)
+			if c.err != "" {
+				if err == nil || err.Error() != c.err {
+					t.Errorf("Expected %q, got %v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(port, c.expected); diff != "" {
+				t.Errorf("Result differs: (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewSloppyFileLongPortSyntax(t *testing.T) {
+	buf := []byte(`version: "3.9"
+services:
+  web:
+    image: nginx
+    ports:
+    - target: 8080
+      published: 80
+      protocol: tcp
+      mode: host
+`)
+	cf, err := converter.NewComposeFile(buf, "ports")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf, err := converter.NewSloppyFile(cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port := sf.Services["apps"]["web"].Port; port == nil || *port != 8080 {
+		t.Errorf("Expected the container port 8080, got %v", port)
+	}
+}
+
+func TestNewSloppyFileExpose(t *testing.T) {
+	buf := []byte(`services:
+  web:
+    image: nginx
+    ports:
+    - "80:8080"
+    expose:
+    - "9090"
+    environment:
+      API_URL: http://api:9000/v1
+  api:
+    image: api
+    expose:
+    - "9000"
+    - "9001/udp"
+`)
+	cf, err := converter.NewComposeFile(buf, "expose")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf, err := converter.NewSloppyFile(cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	linker := &converter.Linker{}
+	if err := linker.Resolve(cf, sf); err != nil {
+		t.Fatal(err)
+	}
+
+	apps := sf.Services["apps"]
+	if port := apps["web"].Port; port == nil || *port != 8080 {
+		t.Errorf("Expected the published container port 8080, got %v", port)
+	}
+	if port := apps["api"].Port; port == nil || *port != 9000 {
+		t.Errorf("Expected the exposed port 9000, got %v", port)
+	}
+	if diff := cmp.Diff(apps["web"].App.EnvVars["API_URL"], "http://api.apps.expose:9000/v1"); diff != "" {
+		t.Errorf("Result differs: (-got +want)\n%s", diff)
+	}
+	warnings := []string{`Service "api": UDP ports are not supported by sloppy.io: "9001/udp". Skipping the exposed port.`}
+	if diff := cmp.Diff(sf.Warnings, warnings); diff != "" {
+		t.Errorf("Warnings differ: (-got +want)\n%s", diff)
+	}
+}
@@ -0,0 +1,238 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	sloppy "github.com/sloppyio/cli/pkg/api"
+)
+
+// serviceRoute is the public routing of a service declared for a reverse
+// proxy like Traefik or nginx-proxy.
+type serviceRoute struct {
+	Hosts []string
+	// Port is the container port requests are routed to, 0 if not set.
+	Port int
+	// SSL, RedirectHTTPS and HSTS are nil unless the route sets them.
+	SSL           *bool
+	RedirectHTTPS *bool
+	HSTS          *bool
+}
+
+// Returns the route declared by the Traefik labels or the nginx-proxy
+// environment of the service, nil if there is none.
+func findRoute(service *ComposeService) (*serviceRoute, error) {
+	route, err := traefikRoute(service.Labels)
+	if route != nil || err != nil {
+		return route, err
+	}
+	return nginxProxyRoute(service.Environment)
+}
+
+var (
+	traefikHostRegex = regexp.MustCompile("Host(?:SNI)?\\(([^)]*)\\)")
+	// `traefik.http.routers.web.rule`, the kind, name and option
+	traefikHTTPRegex = regexp.MustCompile(`^traefik\.http\.(routers|middlewares|services)\.([^.]+)\.(.+)$`)
+)
+
+// traefikRouter holds the options of a Traefik 2 router.
+type traefikRouter struct {
+	hosts       []string
+	tls         *bool
+	middlewares []string
+	service     string
+}
+
+// Reads the routers of Traefik 2 (`traefik.http.routers.web.rule`) and the
+// frontend of Traefik 1 (`traefik.frontend.rule`).
+func traefikRoute(labels map[string]string) (*serviceRoute, error) {
+	if enabled, ok := labels["traefik.enable"]; ok && enabled != "true" {
+		return nil, nil
+	}
+
+	var keys []string
+	for key := range labels {
+		if strings.HasPrefix(key, "traefik.") {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	sort.Strings(keys)
+
+	route := &serviceRoute{}
+	var routerNames []string
+	routers := make(map[string]*traefikRouter)
+	// the lower case options of the middlewares by their name
+	middlewares := make(map[string]map[string]string)
+	ports := make(map[string]int)
+	for _, key := range keys {
+		val := labels[key]
+		match := traefikHTTPRegex.FindStringSubmatch(key)
+		if match == nil {
+			// Traefik 1
+			switch {
+			case key == "traefik.frontend.rule":
+				// `Host:a.example.com,b.example.com`
+				for _, rule := range strings.Split(val, ";") {
+					if strings.HasPrefix(rule, "Host:") {
+						route.Hosts = append(route.Hosts, splitHosts(strings.TrimPrefix(rule, "Host:"))...)
+					}
+				}
+			case key == "traefik.port":
+				port, err := parsePortNumber(val)
+				if err != nil {
+					return nil, fmt.Errorf("label %q: invalid port %q", key, val)
+				}
+				route.Port = port
+			case key == "traefik.frontend.redirect.entryPoint" && val == "https":
+				route.RedirectHTTPS = sloppy.Bool(true)
+			case strings.ToLower(key) == "traefik.frontend.headers.stsseconds":
+				seconds, _ := strconv.Atoi(val)
+				route.HSTS = sloppy.Bool(seconds > 0)
+			}
+			continue
+		}
+
+		name, option := match[2], match[3]
+		switch match[1] {
+		case "routers":
+			router := routers[name]
+			if router == nil {
+				router = &traefikRouter{}
+				routers[name] = router
+				routerNames = append(routerNames, name)
+			}
+			switch {
+			case option == "rule":
+				for _, hosts := range traefikHostRegex.FindAllStringSubmatch(val, -1) {
+					router.hosts = append(router.hosts, splitHosts(hosts[1])...)
+				}
+			case option == "tls":
+				router.tls = sloppy.Bool(val == "true")
+			case strings.HasPrefix(option, "tls."):
+				router.tls = sloppy.Bool(true)
+			case option == "middlewares":
+				for _, middleware := range strings.Split(val, ",") {
+					router.middlewares = append(router.middlewares, traefikName(middleware))
+				}
+			case option == "service":
+				router.service = traefikName(val)
+			}
+		case "middlewares":
+			if middlewares[name] == nil {
+				middlewares[name] = make(map[string]string)
+			}
+			middlewares[name][strings.ToLower(option)] = val
+		case "services":
+			if option != "loadbalancer.server.port" {
+				continue
+			}
+			port, err := parsePortNumber(val)
+			if err != nil {
+				return nil, fmt.Errorf("label %q: invalid port %q", key, val)
+			}
+			ports[name] = port
+		}
+	}
+
+	// the first router with hosts is used, TLS, redirects, HSTS and the
+	// port are only taken from its own options, middlewares and service
+	for _, name := range routerNames {
+		router := routers[name]
+		if len(router.hosts) == 0 {
+			continue
+		}
+		route.Hosts = append(route.Hosts, router.hosts...)
+		route.SSL = router.tls
+		for _, middleware := range router.middlewares {
+			options := middlewares[middleware]
+			if options["redirectscheme.scheme"] == "https" {
+				route.RedirectHTTPS = sloppy.Bool(true)
+			}
+			if val, ok := options["headers.stsseconds"]; ok {
+				seconds, _ := strconv.Atoi(val)
+				route.HSTS = sloppy.Bool(seconds > 0)
+			}
+		}
+		// routers without service use the only service of the container
+		service := router.service
+		if service == "" && len(ports) == 1 {
+			for name := range ports {
+				service = name
+			}
+		}
+		if port, ok := ports[service]; ok {
+			route.Port = port
+		}
+		break
+	}
+	if len(route.Hosts) == 0 {
+		return nil, nil
+	}
+	return route, nil
+}
+
+// Returns the name of a Traefik reference without its provider, e.g.
+// `redirect` of `redirect@file`.
+func traefikName(ref string) string {
+	return strings.SplitN(strings.TrimSpace(ref), "@", 2)[0]
+}
+
+// Reads the `VIRTUAL_HOST`, `VIRTUAL_PORT` and `HTTPS_METHOD` variables
+// of nginx-proxy.
+func nginxProxyRoute(environment map[string]*string) (*serviceRoute, error) {
+	value := func(key string) string {
+		if val := environment[key]; val != nil {
+			return *val
+		}
+		return ""
+	}
+
+	hosts := splitHosts(value("VIRTUAL_HOST"))
+	if len(hosts) == 0 {
+		return nil, nil
+	}
+	route := &serviceRoute{Hosts: hosts}
+	if port := value("VIRTUAL_PORT"); port != "" {
+		var err error
+		route.Port, err = parsePortNumber(port)
+		if err != nil {
+			return nil, fmt.Errorf("invalid VIRTUAL_PORT %q", port)
+		}
+	}
+	if value("LETSENCRYPT_HOST") != "" {
+		route.SSL = sloppy.Bool(true)
+	}
+	switch value("HTTPS_METHOD") {
+	case "redirect":
+		route.RedirectHTTPS = sloppy.Bool(true)
+	case "noredirect", "nohttp":
+		route.RedirectHTTPS = sloppy.Bool(false)
+	case "nohttps":
+		route.SSL = sloppy.Bool(false)
+		route.RedirectHTTPS = sloppy.Bool(false)
+	}
+	if value("HSTS") == "off" {
+		route.HSTS = sloppy.Bool(false)
+	}
+	return route, nil
+}
+
+// Splits comma separated hosts, quotes of Traefik rules are removed.
+// Hosts with patterns can't be used as domain and are left out.
+func splitHosts(s string) []string {
+	var hosts []string
+	for _, host := range strings.Split(s, ",") {
+		host = strings.Trim(strings.TrimSpace(host), "`\"'")
+		if host == "" || strings.ContainsAny(host, "{*~") {
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
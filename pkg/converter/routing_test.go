@@ -0,0 +1,167 @@
+package converter_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	sloppy "github.com/sloppyio/cli/pkg/api"
+	"github.com/sloppyio/sloppose/pkg/converter"
+)
+
+func TestNewSloppyFileRouting(t *testing.T) {
+	cases := map[string]struct {
+		service  string
+		domain   *sloppy.Domain
+		ssl      *bool
+		port     *int
+		warnings []string
+	}{
+		"traefik 2": {
+			service: `
+    ports:
+    - "9090"
+    - "8080"
+    labels:
+      traefik.enable: "true"
+      traefik.http.routers.web.rule: Host(` + "`web.example.com`" + `) && PathPrefix(` + "`/`" + `)
+      traefik.http.routers.web.tls.certresolver: letsencrypt
+      traefik.http.routers.web.middlewares: https-redirect, hsts@docker
+      traefik.http.middlewares.https-redirect.redirectscheme.scheme: https
+      traefik.http.middlewares.hsts.headers.stsSeconds: "31536000"
+      traefik.http.services.web.loadbalancer.server.port: "8080"`,
+			domain: &sloppy.Domain{
+				URI:           sloppy.String("web.example.com"),
+				RedirectHttps: sloppy.Bool(true),
+				HstsHeader:    sloppy.Bool(true),
+			},
+			ssl:  sloppy.Bool(true),
+			port: sloppy.Int(8080),
+			warnings: []string{
+				`Service "web": only the primary port 8080 is converted, the ports 9090 are dropped.`,
+			},
+		},
+		"traefik 2 with multiple hosts": {
+			service: `
+    labels:
+      traefik.http.routers.web.rule: Host(` + "`a.example.com`, `b.example.com`" + `)`,
+			domain: &sloppy.Domain{URI: sloppy.String("a.example.com")},
+			ssl:    sloppy.Bool(true),
+			warnings: []string{
+				`Service "web": sloppy.io supports a single domain, using "a.example.com" and dropping b.example.com.`,
+			},
+		},
+		"traefik 2 with multiple routers": {
+			service: `
+    labels:
+      traefik.http.routers.api.rule: PathPrefix(` + "`/api`" + `)
+      traefik.http.routers.api.tls.certresolver: letsencrypt
+      traefik.http.routers.web.rule: Host(` + "`web.example.com`" + `)
+      traefik.http.routers.web.tls: "false"`,
+			domain: &sloppy.Domain{URI: sloppy.String("web.example.com")},
+			ssl:    sloppy.Bool(false),
+		},
+		"traefik 2 with options of another router": {
+			service: `
+    labels:
+      traefik.http.routers.web.rule: Host(` + "`web.example.com`" + `)
+      traefik.http.routers.web.middlewares: hsts
+      traefik.http.routers.web.service: app
+      traefik.http.routers.www.rule: Host(` + "`www.example.com`" + `)
+      traefik.http.routers.www.middlewares: https-redirect
+      traefik.http.routers.www.service: admin
+      traefik.http.middlewares.https-redirect.redirectscheme.scheme: https
+      traefik.http.middlewares.hsts.headers.stsSeconds: "31536000"
+      traefik.http.middlewares.no-hsts.headers.stsSeconds: "0"
+      traefik.http.services.admin.loadbalancer.server.port: "9000"
+      traefik.http.services.app.loadbalancer.server.port: "8080"`,
+			domain: &sloppy.Domain{
+				URI:        sloppy.String("web.example.com"),
+				HstsHeader: sloppy.Bool(true),
+			},
+			ssl:  sloppy.Bool(true),
+			port: sloppy.Int(8080),
+		},
+		"traefik disabled": {
+			service: `
+    domainname: web.sloppy.zone
+    labels:
+      traefik.enable: "false"
+      traefik.http.routers.web.rule: Host(` + "`web.example.com`" + `)`,
+			domain: &sloppy.Domain{URI: sloppy.String("web.sloppy.zone")},
+			ssl:    sloppy.Bool(true),
+		},
+		"traefik 1": {
+			service: `
+    expose:
+    - "3000"
+    labels:
+      traefik.frontend.rule: Host:web.example.com
+      traefik.frontend.redirect.entryPoint: https
+      traefik.port: "3000"`,
+			domain: &sloppy.Domain{
+				URI:           sloppy.String("web.example.com"),
+				RedirectHttps: sloppy.Bool(true),
+			},
+			ssl:  sloppy.Bool(true),
+			port: sloppy.Int(3000),
+		},
+		"nginx-proxy": {
+			service: `
+    domainname: ignored.sloppy.zone
+    environment:
+      VIRTUAL_HOST: web.example.com
+      VIRTUAL_PORT: "8000"
+      HTTPS_METHOD: redirect
+      HSTS: "off"`,
+			domain: &sloppy.Domain{
+				URI:           sloppy.String("web.example.com"),
+				RedirectHttps: sloppy.Bool(true),
+				HstsHeader:    sloppy.Bool(false),
+			},
+			ssl:  sloppy.Bool(true),
+			port: sloppy.Int(8000),
+		},
+		"nginx-proxy without https": {
+			service: `
+    environment:
+      VIRTUAL_HOST: web.example.com
+      HTTPS_METHOD: nohttps`,
+			domain: &sloppy.Domain{
+				URI:           sloppy.String("web.example.com"),
+				RedirectHttps: sloppy.Bool(false),
+			},
+			ssl: sloppy.Bool(false),
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := []byte(`services:
+  web:
+    image: nginx` + c.service + "\n")
+			cf, err := converter.NewComposeFile(buf, "routing")
+			if err != nil {
+				t.Fatal(err)
+			}
+			sf, err := converter.NewSloppyFile(cf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			web := sf.Services["apps"]["web"]
+			if diff := cmp.Diff(web.App.Domain, c.domain); diff != "" {
+				t.Errorf("Domain differs: (-got +want)\n%s", diff)
+			}
+			if diff := cmp.Diff(web.SSL, c.ssl); diff != "" {
+				t.Errorf("SSL differs: (-got +want)\n%s", diff)
+			}
+			if diff := cmp.Diff(web.Port, c.port); diff != "" {
+				t.Errorf("Port differs: (-got +want)\n%s", diff)
+			}
+			if diff := cmp.Diff(sf.Warnings, c.warnings); diff != "" {
+				t.Errorf("Warnings differ: (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,514 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Message of keys a schema doesn't know.
+const unsupportedMessage = "is not supported, check for typos"
+
+// ValidationError describes a single violation of the compose schema.
+// Line and Column are zero if the position couldn't be determined.
+type ValidationError struct {
+	Filename string
+	Line     int
+	Column   int
+	// Path of the invalid element, e.g. `services.web.ports[1]`.
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	var location string
+	if e.Filename != "" {
+		location = e.Filename + ":"
+	}
+	if e.Line > 0 {
+		location += fmt.Sprintf("%d:%d:", e.Line, e.Column)
+	}
+	if location != "" {
+		location += " "
+	}
+	if e.Path == "" {
+		return location + e.Message
+	}
+	return fmt.Sprintf("%s%s %s", location, e.Path, e.Message)
+}
+
+// ValidationErrors holds all schema violations of a compose file.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	var lines []string
+	for _, err := range e {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SchemaValidator validates compose files against the json schemas of
+// pkg/config. The keywords used by these schemas (draft 04) are supported,
+// formats are not checked.
+type SchemaValidator struct {
+	schema map[string]interface{}
+	// compiled patterns of `patternProperties`, nil for invalid ones
+	patterns map[string]*regexp.Regexp
+}
+
+func NewSchemaValidator(schema string) (*SchemaValidator, error) {
+	sv := &SchemaValidator{patterns: make(map[string]*regexp.Regexp)}
+	err := json.Unmarshal([]byte(schema), &sv.schema)
+	if err != nil {
+		return nil, err
+	}
+	return sv, nil
+}
+
+// Validate checks the given yaml tree. The source is used to determine the
+// line and column of each violation, filename is only used for reporting.
+func (sv *SchemaValidator) Validate(filename string, source []byte, tree map[string]interface{}) error {
+	errs := sv.validate("", tree, sv.schema)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	locator := newYAMLLocator(source)
+	for _, err := range errs {
+		err.Filename = filename
+		err.Line, err.Column = locator.Locate(err.Path)
+	}
+	return errs
+}
+
+func (sv *SchemaValidator) validate(path string, value interface{}, schema map[string]interface{}) ValidationErrors {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, err := sv.resolve(ref)
+		if err != nil {
+			return ValidationErrors{{Path: path, Message: err.Error()}}
+		}
+		return sv.validate(path, value, resolved)
+	}
+
+	if types, ok := schema["type"]; ok && !matchesType(value, types) {
+		return ValidationErrors{{Path: path, Message: "must be " + describeTypes(types)}}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			var values []string
+			for _, e := range enum {
+				values = append(values, fmt.Sprintf("%q", fmt.Sprint(e)))
+			}
+			return ValidationErrors{{Path: path, Message: "must be one of " + strings.Join(values, ", ")}}
+		}
+	}
+
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		matches, errs := sv.matchSchemas(path, value, oneOf)
+		if matches == 0 {
+			return errs
+		}
+		if matches > 1 {
+			return ValidationErrors{{Path: path, Message: fmt.Sprintf("is ambiguous, it matches %d of the allowed formats", matches)}}
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		if matches, errs := sv.matchSchemas(path, value, anyOf); matches == 0 {
+			return errs
+		}
+	}
+	var errs ValidationErrors
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, s := range allOf {
+			if sub, ok := s.(map[string]interface{}); ok {
+				errs = append(errs, sv.validate(path, value, sub)...)
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		errs = append(errs, sv.validateObject(path, v, schema)...)
+	case []interface{}:
+		errs = append(errs, sv.validateArray(path, v, schema)...)
+	case float64:
+		if min, ok := schema["minimum"].(float64); ok && v < min {
+			errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("must be at least %v", min)})
+		}
+		if max, ok := schema["maximum"].(float64); ok && v > max {
+			errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("must be at most %v", max)})
+		}
+	}
+	return errs
+}
+
+// Returns the number of the given schemas the value matches. If none
+// matches, the errors of the first schema accepting the type of the value
+// are returned.
+func (sv *SchemaValidator) matchSchemas(path string, value interface{}, schemas []interface{}) (int, ValidationErrors) {
+	var matches int
+	var candidate ValidationErrors
+	var types []interface{}
+	for _, s := range schemas {
+		sub, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if ref, ok := sub["$ref"].(string); ok {
+			resolved, err := sv.resolve(ref)
+			if err != nil {
+				return 0, ValidationErrors{{Path: path, Message: err.Error()}}
+			}
+			sub = resolved
+		}
+
+		errs := sv.validate(path, value, sub)
+		if len(errs) == 0 {
+			matches++
+			continue
+		}
+		t, ok := sub["type"]
+		if !ok || matchesType(value, t) {
+			if candidate == nil {
+				candidate = errs
+			}
+			continue
+		}
+		switch t := t.(type) {
+		case []interface{}:
+			types = append(types, t...)
+		default:
+			types = append(types, t)
+		}
+	}
+	if matches > 0 {
+		return matches, nil
+	}
+	if candidate != nil {
+		return 0, candidate
+	}
+	return 0, ValidationErrors{{Path: path, Message: "must be " + describeTypes(types)}}
+}
+
+func (sv *SchemaValidator) validateObject(path string, value map[string]interface{}, schema map[string]interface{}) ValidationErrors {
+	var errs ValidationErrors
+
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, r := range required {
+			if _, ok := value[fmt.Sprint(r)]; !ok {
+				errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("is missing the required property %q", r)})
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	patternProperties, _ := schema["patternProperties"].(map[string]interface{})
+
+	var keys []string
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		p := joinPath(path, key)
+		matched := false
+		if s, ok := properties[key].(map[string]interface{}); ok {
+			matched = true
+			errs = append(errs, sv.validate(p, value[key], s)...)
+		}
+		for pattern, s := range patternProperties {
+			if re := sv.pattern(pattern); re != nil && re.MatchString(key) {
+				matched = true
+				if sub, ok := s.(map[string]interface{}); ok {
+					errs = append(errs, sv.validate(p, value[key], sub)...)
+				}
+			}
+		}
+		if matched {
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				errs = append(errs, &ValidationError{Path: p, Message: unsupportedMessage})
+			}
+		case map[string]interface{}:
+			errs = append(errs, sv.validate(p, value[key], additional)...)
+		}
+	}
+	return errs
+}
+
+func (sv *SchemaValidator) validateArray(path string, value []interface{}, schema map[string]interface{}) ValidationErrors {
+	var errs ValidationErrors
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		for i, item := range value {
+			errs = append(errs, sv.validate(fmt.Sprintf("%s[%d]", path, i), item, items)...)
+		}
+	}
+	if unique, ok := schema["uniqueItems"].(bool); ok && unique {
+		for i := range value {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(value[i], value[j]) {
+					errs = append(errs, &ValidationError{
+						Path:    fmt.Sprintf("%s[%d]", path, i),
+						Message: fmt.Sprintf("duplicates %s[%d]", path, j),
+					})
+					break
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// Convert replaces the strings of the given tree by the integer, number or
+// boolean the schema expects, like docker-compose does for interpolated
+// values. Only strings whose raw value, the tree before interpolation,
+// refers to a variable are converted. Strings are kept if the schema
+// accepts them.
+func (sv *SchemaValidator) Convert(tree, raw map[string]interface{}) {
+	sv.convert(tree, raw, sv.schema)
+}
+
+func (sv *SchemaValidator) convert(value, raw interface{}, schema map[string]interface{}) interface{} {
+	branches := sv.branches(schema)
+	switch v := value.(type) {
+	case string:
+		if r, ok := raw.(string); ok && strings.Contains(r, "$") {
+			return convertScalar(v, branches)
+		}
+	case map[string]interface{}:
+		r, _ := raw.(map[string]interface{})
+		for key, val := range v {
+			for _, branch := range branches {
+				for _, sub := range sv.propertySchemas(branch, key) {
+					val = sv.convert(val, r[key], sub)
+				}
+			}
+			v[key] = val
+		}
+	case []interface{}:
+		r, _ := raw.([]interface{})
+		for i := range v {
+			var rawItem interface{}
+			if i < len(r) {
+				rawItem = r[i]
+			}
+			for _, branch := range branches {
+				if items, ok := branch["items"].(map[string]interface{}); ok {
+					v[i] = sv.convert(v[i], rawItem, items)
+				}
+			}
+		}
+	}
+	return value
+}
+
+// Returns the schema and the schemas of its `oneOf`, `anyOf` and `allOf`
+// keywords with resolved references.
+func (sv *SchemaValidator) branches(schema map[string]interface{}) []map[string]interface{} {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, err := sv.resolve(ref)
+		if err != nil {
+			return nil
+		}
+		schema = resolved
+	}
+	branches := []map[string]interface{}{schema}
+	for _, keyword := range []string{"oneOf", "anyOf", "allOf"} {
+		schemas, _ := schema[keyword].([]interface{})
+		for _, s := range schemas {
+			if sub, ok := s.(map[string]interface{}); ok {
+				branches = append(branches, sv.branches(sub)...)
+			}
+		}
+	}
+	return branches
+}
+
+// Returns the schemas of the given property of an object.
+func (sv *SchemaValidator) propertySchemas(schema map[string]interface{}, key string) []map[string]interface{} {
+	var schemas []map[string]interface{}
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		if s, ok := properties[key].(map[string]interface{}); ok {
+			schemas = append(schemas, s)
+		}
+	}
+	patternProperties, _ := schema["patternProperties"].(map[string]interface{})
+	for pattern, s := range patternProperties {
+		if re := sv.pattern(pattern); re != nil && re.MatchString(key) {
+			if sub, ok := s.(map[string]interface{}); ok {
+				schemas = append(schemas, sub)
+			}
+		}
+	}
+	if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok && len(schemas) == 0 {
+		schemas = append(schemas, additional)
+	}
+	return schemas
+}
+
+// Returns the value as the first type of integer, number and boolean the
+// schemas accept. Strings are kept if the schemas accept them along with
+// several of these types, like the values of `environment`. A single one
+// besides strings, like `["integer", "string"]` of the compose
+// specification, is meant for interpolated values.
+func convertScalar(value string, schemas []map[string]interface{}) interface{} {
+	accepted := make(map[string]bool)
+	for _, schema := range schemas {
+		switch t := schema["type"].(type) {
+		case string:
+			accepted[t] = true
+		case []interface{}:
+			for _, e := range t {
+				accepted[fmt.Sprint(e)] = true
+			}
+		}
+	}
+	var scalars int
+	for _, t := range []string{"integer", "number", "boolean"} {
+		if accepted[t] {
+			scalars++
+		}
+	}
+	if accepted["string"] && scalars > 1 {
+		return value
+	}
+	if accepted["integer"] {
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return float64(i)
+		}
+	}
+	if accepted["number"] {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	if accepted["boolean"] {
+		switch strings.ToLower(value) {
+		case "true", "yes", "y", "on":
+			return true
+		case "false", "no", "n", "off":
+			return false
+		}
+	}
+	return value
+}
+
+// Returns the compiled pattern, nil if it's invalid. Patterns are
+// compiled once per validator.
+func (sv *SchemaValidator) pattern(pattern string) *regexp.Regexp {
+	re, ok := sv.patterns[pattern]
+	if !ok {
+		re, _ = regexp.Compile(pattern)
+		sv.patterns[pattern] = re
+	}
+	return re
+}
+
+// Resolves local references like `#/definitions/service`.
+func (sv *SchemaValidator) resolve(ref string) (map[string]interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported schema reference %q", ref)
+	}
+	var node interface{} = sv.schema
+	for _, part := range strings.Split(ref[2:], "/") {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unknown schema reference %q", ref)
+		}
+		node = m[part]
+	}
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unknown schema reference %q", ref)
+	}
+	return schema, nil
+}
+
+func matchesType(value interface{}, types interface{}) bool {
+	switch t := types.(type) {
+	case string:
+		return matchesSingleType(value, t)
+	case []interface{}:
+		for _, e := range t {
+			if s, ok := e.(string); ok && matchesSingleType(value, s) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesSingleType(value interface{}, t string) bool {
+	switch v := value.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case float64:
+		return t == "number" || (t == "integer" && v == math.Trunc(v))
+	case []interface{}:
+		return t == "array"
+	case map[string]interface{}:
+		return t == "object"
+	}
+	return false
+}
+
+// Returns e.g. `a string or array` for the given schema types.
+func describeTypes(types interface{}) string {
+	var names []string
+	switch t := types.(type) {
+	case string:
+		names = []string{t}
+	case []interface{}:
+		seen := make(map[string]bool)
+		for _, e := range t {
+			name := fmt.Sprint(e)
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return "valid"
+	}
+
+	article := "a"
+	if strings.IndexAny(names[0][:1], "aeiou") == 0 {
+		article = "an"
+	}
+	if len(names) == 1 {
+		return article + " " + names[0]
+	}
+	return article + " " + strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
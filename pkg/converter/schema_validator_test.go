@@ -0,0 +1,252 @@
+package converter_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sloppyio/sloppose/pkg/converter"
+)
+
+func TestComposeLoader_Validation(t *testing.T) {
+	tt := []struct {
+		name     string
+		compose  string
+		expected converter.ValidationErrors
+	}{
+		{
+			name: "unknown service key",
+			compose: `version: "3"
+services:
+  web:
+    image: nginx
+    enviroment:
+      FOO: bar
+`,
+			expected: converter.ValidationErrors{
+				{Line: 5, Column: 5, Path: "services.web.enviroment", Message: "is not supported, check for typos"},
+			},
+		},
+		{
+			name: "invalid sequence item",
+			compose: `version: "3.4"
+services:
+  web:
+    image: nginx
+    ports:
+      - "80:80"
+      - {target: [80]}
+`,
+			expected: converter.ValidationErrors{
+				{Line: 7, Column: 9, Path: "services.web.ports[1].target", Message: "must be an integer"},
+			},
+		},
+		{
+			name: "flow sequence item",
+			compose: `version: "2.4"
+services:
+  web:
+    image: nginx
+    expose: ["80", true]
+`,
+			expected: converter.ValidationErrors{
+				{Line: 5, Column: 20, Path: "services.web.expose[1]", Message: "must be a string or number"},
+			},
+		},
+		{
+			name: "compose specification",
+			compose: `services:
+  web:
+    image: nginx
+    port: 80
+    depends_on:
+      db:
+        condition: service_ready
+  db:
+    image: postgres
+`,
+			expected: converter.ValidationErrors{
+				{Line: 7, Column: 9, Path: "services.web.depends_on.db.condition", Message: `must be one of "service_started", "service_healthy", "service_completed_successfully"`},
+				{Line: 4, Column: 5, Path: "services.web.port", Message: "is not supported, check for typos"},
+			},
+		},
+		{
+			name: "keys of newer versions",
+			compose: `version: "3.6"
+services:
+  web:
+    image: nginx
+    init: true
+    deploy:
+      placement:
+        max_replicas_per_node: 1
+`,
+			expected: converter.ValidationErrors{
+				{Line: 8, Column: 9, Path: "services.web.deploy.placement.max_replicas_per_node", Message: "requires version 3.8 or later, the file declares 3.6"},
+				{Line: 5, Column: 5, Path: "services.web.init", Message: "requires version 3.7 or later, the file declares 3.6"},
+			},
+		},
+		{
+			name: "anchors",
+			compose: `version: "3.4"
+x-defaults: &defaults
+  image: nginx
+services:
+  web: &web
+    image: nginx
+    enviroment: !!map
+      FOO: bar
+`,
+			expected: converter.ValidationErrors{
+				{Line: 7, Column: 5, Path: "services.web.enviroment", Message: "is not supported, check for typos"},
+			},
+		},
+		{
+			name: "aliases",
+			compose: `version: "3.4"
+x-defaults: &defaults
+  enviroment:
+    FOO: bar
+services:
+  web:
+    <<: *defaults
+    image: nginx
+`,
+			expected: converter.ValidationErrors{
+				{Line: 6, Column: 3, Path: "services.web.enviroment", Message: "is not supported, check for typos"},
+			},
+		},
+		{
+			name: "multi line flow sequence",
+			compose: `version: "3.4"
+services:
+  web:
+    image: nginx
+    ports: [
+      "80:80", # http
+      {target: [80]}
+    ]
+    enviroment: {}
+`,
+			expected: converter.ValidationErrors{
+				{Line: 9, Column: 5, Path: "services.web.enviroment", Message: "is not supported, check for typos"},
+				{Line: 7, Column: 7, Path: "services.web.ports[1].target", Message: "must be an integer"},
+			},
+		},
+		{
+			name: "keys of the specification",
+			compose: `version: "3.6"
+services:
+  web:
+    image: nginx
+    profiles: [debug]
+`,
+			expected: converter.ValidationErrors{
+				{Line: 5, Column: 5, Path: "services.web.profiles", Message: "requires the compose specification, remove the version 3.6 of the file"},
+			},
+		},
+		{
+			name: "service extensions",
+			compose: `version: "3.8"
+services:
+  web:
+    image: nginx
+    init: true
+    x-custom: true
+`,
+		},
+		{
+			name: "valid",
+			compose: `version: "3"
+services:
+  web:
+    image: nginx
+x-defaults:
+  foo: bar
+`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			loader := &converter.ComposeLoader{}
+			_, _, err := loader.Load(&converter.ComposeSource{Content: []byte(tc.compose)})
+			if tc.expected == nil {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+
+			errs, ok := err.(converter.ValidationErrors)
+			if !ok {
+				t.Fatalf("Expected validation errors, got %v", err)
+			}
+			if diff := cmp.Diff(errs, tc.expected); diff != "" {
+				t.Errorf("Errors differ: (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestComposeLoader_ValidationFilename(t *testing.T) {
+	source := &converter.ComposeSource{
+		Filename: "docker-compose.yml",
+		Content:  []byte("version: \"3\"\nservices:\n  web:\n    imagee: nginx\n"),
+	}
+	_, err := converter.NewComposeFileWithOptions([]*converter.ComposeSource{source}, &converter.ComposeOptions{ProjectName: ""})
+
+	expected := `docker-compose.yml:4:5: services.web.imagee is not supported, check for typos`
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error %q, got %v", expected, err)
+	}
+}
+
+func TestSchemaValidator_OneOf(t *testing.T) {
+	validator, err := converter.NewSchemaValidator(`{
+  "type": "object",
+  "properties": {
+    "size": {"oneOf": [{"type": "number"}, {"type": "integer"}, {"type": "string"}]}
+  },
+  "patternProperties": {"^x-": {}},
+  "additionalProperties": false
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		name     string
+		tree     map[string]interface{}
+		expected converter.ValidationErrors
+	}{
+		{name: "single match", tree: map[string]interface{}{"size": "1G", "x-size": true}},
+		{
+			name: "multiple matches",
+			tree: map[string]interface{}{"size": float64(1)},
+			expected: converter.ValidationErrors{
+				{Path: "size", Message: "is ambiguous, it matches 2 of the allowed formats"},
+			},
+		},
+		{
+			name: "no match",
+			tree: map[string]interface{}{"size": true, "y-size": true},
+			expected: converter.ValidationErrors{
+				{Path: "size", Message: "must be a number, integer or string"},
+				{Path: "y-size", Message: "is not supported, check for typos"},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validator.Validate("", nil, tc.tree)
+			var errs converter.ValidationErrors
+			if err != nil {
+				errs = err.(converter.ValidationErrors)
+			}
+			if diff := cmp.Diff(errs, tc.expected); diff != "" {
+				t.Errorf("Result differs: (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
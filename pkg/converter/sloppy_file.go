@@ -1,19 +1,28 @@
 package converter
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
-	"math"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 
 	sloppy "github.com/sloppyio/cli/pkg/api"
+	"github.com/sloppyio/sloppose/pkg/config"
+)
+
+const (
+	// PortModeFirst converts the primary port of each app only.
+	PortModeFirst = "first"
+	// PortModeAll converts all ports of each app to port mappings.
+	PortModeAll = "all"
 )
 
 var (
 	ErrBuildNotSupported = errors.New("the build property is not supported, please specify an image instead")
+	ErrPortMode          = errors.New(`the port mode has to be "first" or "all"`)
 )
 
 type SloppyApps map[string]*SloppyApp
@@ -24,19 +33,81 @@ type SloppyEnvSlice []map[string]string
 // the yml and json format representation for the sloppy.App struct.
 type SloppyApp struct {
 	*sloppy.App
-	Domain *string        `json:"domain,omitempty"`
+	Domain *SloppyDomain  `json:"domain,omitempty"`
 	Env    SloppyEnvSlice `json:"env,omitempty"`
 	Port   *int           `json:"port,omitempty"`
 
+	// Port mappings are only written if all ports are converted.
+	PortMappings []*sloppy.PortMap `json:"port_mappings,omitempty"`
+
 	// hide conflicting fields from sloppy.App during serialization
-	EnvVars      map[string]string `json:"-"`
-	PortMappings []*sloppy.PortMap `json:"-"`
+	EnvVars map[string]string `json:"-"`
+}
+
+// SloppyDomain is written as its uri unless further options are set.
+type SloppyDomain struct {
+	*sloppy.Domain
+}
+
+func (d *SloppyDomain) MarshalJSON() ([]byte, error) {
+	domain := *d.Domain
+	domain.URI = nil
+	if domain == (sloppy.Domain{}) {
+		return json.Marshal(d.URI)
+	}
+	return json.Marshal(d.Domain)
 }
 
 type SloppyFile struct {
 	Version  string                `json:"version,omitempty"`
 	Project  string                `json:"project,omitempty"`
 	Services map[string]SloppyApps `json:"services,omitempty"`
+
+	// Warnings describe parts of the compose services which couldn't
+	// be converted exactly.
+	Warnings []string `json:"-"`
+}
+
+// SloppyOptions control how compose services are converted.
+type SloppyOptions struct {
+	// PortMode is either PortModeFirst, the default, or PortModeAll.
+	PortMode string
+	// VolumeSize is the size of volumes without a size of their own,
+	// e.g. `8GB`. sloppy.io decides if not set.
+	VolumeSize string
+	// DefaultMemory is the memory of apps without a memory limit or
+	// reservation, e.g. `256M`. sloppy.io decides if not set.
+	DefaultMemory string
+	// RoundMemory rounds memory sizes up to the sizes of MemorySizes.
+	RoundMemory bool
+}
+
+// Returns the port a domain of the app routes to, nil without ports.
+func (a *SloppyApp) primaryPort() *int {
+	if a.Port != nil {
+		return a.Port
+	}
+	if len(a.PortMappings) > 0 {
+		return a.PortMappings[0].Port
+	}
+	return nil
+}
+
+// Returns the container ports of the converted app, which may differ from
+// those of the compose service due to the port mode or x-sloppy settings.
+func (a *SloppyApp) containerPorts() []int {
+	var ports []int
+	if a.Port != nil {
+		ports = append(ports, *a.Port)
+	}
+	for _, portMappings := range [][]*sloppy.PortMap{a.PortMappings, a.App.PortMappings} {
+		for _, portMap := range portMappings {
+			if portMap.Port != nil {
+				ports = append(ports, *portMap.Port)
+			}
+		}
+	}
+	return ports
 }
 
 func (p SloppyEnvSlice) Len() int { return len(p) }
@@ -52,253 +123,326 @@ func (p SloppyEnvSlice) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
 
 // Map docker-compose.yml to sloppy yml and return representation
 func NewSloppyFile(cf *ComposeFile) (*SloppyFile, error) {
+	return NewSloppyFileWithOptions(cf, &SloppyOptions{})
+}
+
+// NewSloppyFileWithOptions works like NewSloppyFile, the given options
+// control how the services are converted.
+func NewSloppyFileWithOptions(cf *ComposeFile, options *SloppyOptions) (*SloppyFile, error) {
+	switch options.PortMode {
+	case "":
+		options.PortMode = PortModeFirst
+	case PortModeFirst, PortModeAll:
+	default:
+		return nil, ErrPortMode
+	}
+	if options.VolumeSize != "" {
+		if _, err := convertVolumeSize(options.VolumeSize); err != nil {
+			return nil, err
+		}
+	}
+	if options.DefaultMemory != "" {
+		if _, err := parseByteSize(options.DefaultMemory); err != nil {
+			return nil, fmt.Errorf("invalid default memory %q", options.DefaultMemory)
+		}
+	}
 	sf := &SloppyFile{
 		Version:  "v1",
 		Project:  cf.ProjectName,
 		Services: map[string]SloppyApps{"apps": make(SloppyApps)},
 	}
 
-	for service, config := range cf.ServiceConfigs {
-		if config.Build != nil {
+	settings, err := loadSettings(cf.Project)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range cf.Project.ServiceNames() {
+		service := cf.Project.Services[name]
+		if service.Build != "" {
 			return nil, ErrBuildNotSupported
 		}
 
 		var uri *string
-		if config.Domainname != "" {
-			uri = &config.Domainname
+		if service.Domainname != "" {
+			uri = &service.Domainname
+		}
+		// routes of reverse proxies take precedence over the domainname
+		route, err := findRoute(service)
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %v", name, err)
+		}
+		if route != nil {
+			uri = &route.Hosts[0]
+			if len(route.Hosts) > 1 {
+				sf.Warnings = append(sf.Warnings, fmt.Sprintf(
+					"Service %q: sloppy.io supports a single domain, using %q and dropping %s.",
+					name, route.Hosts[0], strings.Join(route.Hosts[1:], ", ")))
+			}
 		}
 
 		app := &SloppyApp{
 			App: &sloppy.App{
-				Image:   &config.Image,
-				Volumes: sf.convertVolumes(config.Volumes),
+				Image: &service.Image,
 			},
 		}
 
-		// Entrypoint (string or list)
-		// Prepend to the following commands
-		switch config.Entrypoint.(type) {
-		case string:
-			c := config.Entrypoint.(string)
-			app.App.Command = &c
-		case []interface{}:
-			c := config.Entrypoint.([]interface{})
-			if len(c) > 0 {
-				app.App.Command = sf.convertCommand(c)
-			}
+		volumeSize := options.VolumeSize
+		if settings[name].VolumeSize != "" {
+			volumeSize = settings[name].VolumeSize
 		}
-
-		// Assign possible empty values in extra steps to hide empty object from output
-		// Commands (string or list)
-		switch config.Command.(type) {
-		case string:
-			c := config.Command.(string)
-			if app.App.Command != nil {
-				cmd := fmt.Sprintf("%s %s", *app.App.Command, c)
-				app.App.Command = &cmd
-			} else {
-				app.App.Command = &c
-			}
-		case []interface{}:
-			c := config.Command.([]interface{})
-			if len(c) > 0 {
-				if app.App.Command != nil {
-					cmd := fmt.Sprintf("%s %s", *app.App.Command, *sf.convertCommand(c))
-					app.App.Command = &cmd
-				} else {
-					app.App.Command = sf.convertCommand(c)
-				}
-			}
+		volumes, err := sf.convertVolumes(name, service.Mounts, cf.Project, volumeSize)
+		if err != nil {
+			return nil, err
 		}
+		app.Volumes = volumes
+
+		// Entrypoint is prepended to the command
+		app.App.Command = sf.convertCommand(service.Entrypoint, service.Command)
 
 		// Domain
 		if uri != nil {
 			app.App.Domain = &sloppy.Domain{URI: uri}
-			app.Domain = uri
+			app.Domain = &SloppyDomain{app.App.Domain}
 			app.SSL = sloppy.Bool(true)
+			if route != nil {
+				app.App.Domain.RedirectHttps = route.RedirectHTTPS
+				app.App.Domain.HstsHeader = route.HSTS
+				if route.SSL != nil {
+					app.SSL = route.SSL
+				}
+			}
 		}
 
-		if envList, ok := config.Environment.([]interface{}); ok {
-			app.App.EnvVars = make(map[string]string)
-			for _, e := range envList {
-				env := e.(string)
-				split := strings.Split(env, "=")
-				k, v := split[0], split[1]
-				app.App.EnvVars[k] = v
-				app.Env = append(app.Env, map[string]string{k: v})
-			}
-		} else if envmap, ok := config.Environment.(map[string]interface{}); len(envmap) > 0 && ok {
+		if len(service.Environment) > 0 {
 			app.App.EnvVars = make(map[string]string)
-			for v, val := range envmap {
-				app.App.EnvVars[v] = val.(string)
-			}
-			for k, v := range app.App.EnvVars {
-				app.Env = append(app.Env, map[string]string{k: v})
+			for k, v := range service.Environment {
+				// unresolved keys are omitted like docker-compose does
+				if v == nil {
+					continue
+				}
+				app.App.EnvVars[k] = *v
+				app.Env = append(app.Env, map[string]string{k: *v})
 			}
 		}
 
 		// Logging
-		if config.Logging != nil && config.Logging.Driver != "" && config.Logging.Options != nil {
-			logOpts, ok := config.Logging.Options.(map[string]interface{})
-			if ok && len(logOpts) > 0 {
-				optsMap := make(map[string]string)
-				for k, v := range logOpts {
-					optsMap[k] = v.(string)
-				}
-				app.App.Logging = &sloppy.Logging{
-					Driver:  &config.Logging.Driver,
-					Options: optsMap,
-				}
+		if service.Logging != nil && service.Logging.Driver != "" && len(service.Logging.Options) > 0 {
+			app.App.Logging = &sloppy.Logging{
+				Driver:  &service.Logging.Driver,
+				Options: service.Logging.Options,
 			}
 		}
 
 		// Port
-		if len(config.Ports) > 0 {
-			var ports []string
-			for _, entry := range config.Ports {
-				switch entry.(type) { // number, string, obj
-				case string:
-					ports = append(ports, entry.(string))
-				case float64:
-					ports = append(ports, strconv.Itoa(int(entry.(float64))))
-				case int:
-					p := strconv.Itoa(entry.(int))
-					ports = append(ports, p)
-				}
-			}
-			portMappings, err := sf.convertPorts(ports)
+		var ports []*ServicePort
+		if len(service.Ports) > 0 {
+			ports, err = sf.convertPorts(service.Ports)
 			if err != nil {
 				return nil, err
 			}
+		} else {
+			// internal services need a container port to be reachable
+			ports = sf.convertExpose(name, service.Expose)
+		}
+		var routedPort int
+		if route != nil && route.Port != 0 {
+			routedPort = route.Port
+			ports = append(ports, &ServicePort{Target: routedPort, Protocol: portProtocolTCP})
+		}
+		if len(ports) > 0 {
+			sf.setPorts(name, app, sf.orderPorts(ports, routedPort), options.PortMode)
+		}
 
-			// In yml format just one port is supported, use the first one.
-			// And don't set app.App.PortMappings.
-			app.Port = portMappings[0].Port
+		if service.Healthcheck != nil && !service.Healthcheck.Disable {
+			sf.convertServiceHealthCheck(name, service.Healthcheck, app)
 		}
 
-		if config.Deploy != nil {
-			if config.Deploy.Replicas > 0 {
-				app.Instances = &config.Deploy.Replicas
-			}
-			if config.Deploy.Resources != nil &&
-				config.Deploy.Resources.Limits != nil {
-				var err error
-				app.Memory, err = sf.convertMemoryResource(config.Deploy.Resources.Limits.Memory)
-				if err != nil {
-					return nil, err
-				}
-			}
+		if service.Replicas > 0 {
+			replicas := service.Replicas
+			app.Instances = &replicas
+		}
+		app.ForceRollingDeploy = sf.convertDeploy(name, service)
+		app.Memory, err = sf.convertMemory(name, service, options.DefaultMemory, options.RoundMemory)
+		if err != nil {
+			return nil, err
 		}
 
 		// TODO implement service to compose-file mapping
 		// Possible option to map multiple compose-files to own sloppy services
 		// instead of the current default "apps"
 
+		sf.applySettings(app, settings[name], options.PortMode)
+
 		// sloppy naming:
 		//  []   = service
 		//  [][] = app
-		sf.Services["apps"][service] = app
+		sf.Services["apps"][name] = app
+	}
+	if err := sf.convertDependencyConditions(cf.Project); err != nil {
+		return nil, err
 	}
 	sf.sortFields()
 	return sf, nil
 }
 
-func (sf *SloppyFile) convertCommand(cmd []interface{}) *string {
-	var str string
-	for i, s := range cmd {
-		str += s.(string)
-		if i < len(cmd)-1 {
-			str += " "
-		}
+// Sets the health check of the app, healthchecks which can't be converted
+// are reported. sloppy probes the port of the app, so checks of other
+// ports are reported as well.
+func (sf *SloppyFile) convertServiceHealthCheck(name string, healthcheck *ServiceHealthcheck, app *SloppyApp) {
+	check, target, err := sf.convertHealthCheck(healthcheck)
+	if err != nil {
+		sf.Warnings = append(sf.Warnings, fmt.Sprintf("Service %q: %v. Skipping the healthcheck.", name, err))
+		return
 	}
-	return &str
+	switch port := app.primaryPort(); {
+	case port == nil:
+		sf.Warnings = append(sf.Warnings, fmt.Sprintf(
+			"Service %q: the healthcheck probes port %d, but the service has no port sloppy.io could check.", name, target.Port))
+	case *port != target.Port:
+		sf.Warnings = append(sf.Warnings, fmt.Sprintf(
+			"Service %q: the healthcheck probes port %d, but sloppy.io checks the app port %d.", name, target.Port, *port))
+	}
+	app.HealthChecks = []*sloppy.HealthCheck{check}
 }
 
-var resourceMemRegex = regexp.MustCompile(`^(\d+)([bkmgBKMG])$`)
-
-func (sf *SloppyFile) convertMemoryResource(res string) (*int, error) {
-	const lowestMem float64 = 64 // lowest mem size sloppy.io supports
-	formatErr := fmt.Errorf(`convert resource failed: unsupported memory format: %q in "Deploy.Resources.Limits.Memory"`, res)
-	match := resourceMemRegex.FindStringSubmatch(res)
-	if len(match) == 3 {
-		memResource, err := strconv.Atoi(match[1])
-		if err != nil {
-			return nil, err
+// Applies the conditions of the long `depends_on` syntax. A service
+// waiting for another one to be healthy needs a convertible health check
+// of that service. One-shot jobs can't be awaited, those dependencies
+// are treated like started ones.
+func (sf *SloppyFile) convertDependencyConditions(project *ComposeProject) error {
+	for _, name := range project.ServiceNames() {
+		service := project.Services[name]
+		var deps []string
+		for dep := range service.DependsOn {
+			deps = append(deps, dep)
 		}
-		unit := strings.ToLower(match[2])
-		var mem int
-		switch unit {
-		case "b":
-			mem = int(math.Max(float64(memResource/1024/1024), lowestMem))
-		case "k":
-			mem = int(math.Max(float64(memResource/1024), lowestMem))
-		case "m":
-			mem = memResource
-		case "g":
-			mem = memResource * 1024
-		default:
-			return nil, formatErr
+		sort.Strings(deps)
+
+		for _, dep := range deps {
+			condition := service.DependsOn[dep]
+			target, ok := project.Services[dep]
+			if condition == nil || !ok {
+				continue
+			}
+			switch condition.Condition {
+			case config.ConditionServiceHealthy:
+				if target.Healthcheck == nil {
+					return fmt.Errorf("service %q depends on %q being healthy, but %q has no healthcheck", name, dep, dep)
+				}
+				_, _, err := sf.convertHealthCheck(target.Healthcheck)
+				if err != nil {
+					return fmt.Errorf("service %q depends on %q being healthy, but its healthcheck can't be converted: %v", name, dep, err)
+				}
+			case config.ConditionServiceCompletedSuccessfully:
+				sf.Warnings = append(sf.Warnings, fmt.Sprintf(
+					"Service %q depends on %q completing successfully, but sloppy.io doesn't run one-shot jobs. "+
+						"The dependency is treated like %q.", name, dep, config.ConditionServiceStarted))
+			}
 		}
-		return &mem, nil
 	}
-	return nil, formatErr
+	return nil
 }
 
-func (sf *SloppyFile) convertPorts(ports []string) (pm []*sloppy.PortMap, err error) {
-	const sep = ":"
-	for _, portMap := range ports {
-		var port int
-		if strings.Index(portMap, "-") > -1 {
-			return nil, fmt.Errorf("port ranges are not supported: %q", portMap)
+// Joins the commands to the command line of the app. Strings are kept as
+// written, the arguments of lists are quoted.
+func (sf *SloppyFile) convertCommand(commands ...config.ShellCommand) *string {
+	var parts []string
+	for _, command := range commands {
+		if command.Shell != "" {
+			parts = append(parts, command.Shell)
+			continue
 		}
-		if strings.Index(portMap, sep) > -1 {
-			port, err = strconv.Atoi(strings.Split(portMap, sep)[1])
-		} else {
-			port, err = strconv.Atoi(portMap)
+		for _, arg := range command.Args {
+			parts = append(parts, shellQuote(arg))
 		}
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	str := strings.Join(parts, " ")
+	return &str
+}
+
+var shellSafeRegex = regexp.MustCompile(`^[\w@%+=:,./-]+$`)
+
+func shellQuote(arg string) string {
+	if shellSafeRegex.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
+
+func (sf *SloppyFile) convertPorts(entries []config.ServicePortConfig) ([]*ServicePort, error) {
+	var ports []*ServicePort
+	for _, entry := range entries {
+		port, err := NewServicePort(entry)
 		if err != nil {
 			return nil, err
 		}
-		pm = append(pm, &sloppy.PortMap{Port: &port})
+		ports = append(ports, port)
 	}
+	return ports, nil
+}
 
-	return
+// Converts the exposed ports of a service, ports sloppy.io can't
+// represent are reported and skipped.
+func (sf *SloppyFile) convertExpose(name string, expose []string) []*ServicePort {
+	var ports []*ServicePort
+	for _, spec := range expose {
+		port, err := ParsePort(spec)
+		if err != nil {
+			sf.Warnings = append(sf.Warnings, fmt.Sprintf("Service %q: %v. Skipping the exposed port.", name, err))
+			continue
+		}
+		ports = append(ports, port)
+	}
+	return ports
 }
 
-func (sf *SloppyFile) toVolumes(str string) map[string]string {
-	const sep = ":"
-	out := make(map[string]string)
-	if strings.Index(str, sep) > 0 {
-		parts := strings.Split(str, sep)
-		out["source"], out["target"] = parts[0], parts[1]
-		if strings.Index(parts[1], sep) > 0 {
-			perms := strings.Split(parts[1], sep)
-			out["accessMode"] = perms[1]
+// Returns the port mappings with the primary port first, which is the port
+// a domain routes to. That's the routed port if given, otherwise the
+// container port published as 80 or 443 if any or else the first one.
+// Repeated container ports are dropped.
+func (sf *SloppyFile) orderPorts(ports []*ServicePort, routedPort int) []*sloppy.PortMap {
+	primary := ports[0]
+	for _, port := range ports {
+		if port.Target == routedPort || routedPort == 0 && (port.Published == 80 || port.Published == 443) {
+			primary = port
+			break
+		}
+	}
+
+	ordered := []*sloppy.PortMap{{Port: sloppy.Int(primary.Target)}}
+	seen := map[int]bool{primary.Target: true}
+	for _, port := range ports {
+		if !seen[port.Target] {
+			seen[port.Target] = true
+			ordered = append(ordered, &sloppy.PortMap{Port: sloppy.Int(port.Target)})
 		}
-	} else {
-		out["target"] = str
 	}
-	return out
+	return ordered
 }
 
-func (sf *SloppyFile) convertVolumes(volumes []interface{}) (v []*sloppy.Volume) {
-	if volumes == nil {
+// Sets the ports of the app depending on the port mode. Without all
+// ports, ports besides the primary one are reported.
+func (sf *SloppyFile) setPorts(name string, app *SloppyApp, portMappings []*sloppy.PortMap, mode string) {
+	if mode == PortModeAll {
+		app.PortMappings = portMappings
 		return
 	}
-	for _, volume := range volumes {
-		if vstring, ok := volume.(string); ok {
-			dest := sf.toVolumes(vstring)["target"]
-			v = append(v, &sloppy.Volume{
-				Path: &dest,
-			})
-		} else if vmap, ok := volume.(map[string]interface{}); ok {
-			dest := vmap["target"].(string)
-			v = append(v, &sloppy.Volume{
-				Path: &dest,
-			})
+
+	// In yml format just one port is supported, use the primary one.
+	app.Port = portMappings[0].Port
+	if len(portMappings) > 1 {
+		var dropped []string
+		for _, portMap := range portMappings[1:] {
+			dropped = append(dropped, strconv.Itoa(*portMap.Port))
 		}
+		sf.Warnings = append(sf.Warnings, fmt.Sprintf(
+			"Service %q: only the primary port %d is converted, the ports %s are dropped.",
+			name, *app.Port, strings.Join(dropped, ", ")))
 	}
-	return
 }
 
 // Sorting the converted string slices ensures that
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 
+	sloppy "github.com/sloppyio/cli/pkg/api"
 	"github.com/sloppyio/sloppose/internal/test"
 	"github.com/sloppyio/sloppose/pkg/converter"
 )
@@ -104,3 +105,229 @@ func TestNewComposeFileBuildProperty(t *testing.T) {
 		t.Errorf(`Expected: %q, got: "%v"`, converter.ErrBuildNotSupported.Error(), err)
 	}
 }
+
+func TestNewSloppyFileDependsOnConditions(t *testing.T) {
+	cases := map[string]struct {
+		condition   string
+		compose     string
+		healthCheck *sloppy.HealthCheck
+		warnings    []string
+		err         string
+	}{
+		"service_started": {
+			condition: "service_started",
+		},
+		"service_completed_successfully": {
+			condition: "service_completed_successfully",
+			warnings: []string{`Service "web" depends on "api" completing successfully, but sloppy.io doesn't run one-shot jobs. ` +
+				`The dependency is treated like "service_started".`},
+		},
+		"service_healthy": {
+			condition: "service_healthy",
+			compose: `
+    ports:
+    - "8080"
+    healthcheck:
+      test: ["CMD", "curl", "-f", "http://localhost:8080/health"]
+      interval: 30s
+      timeout: 1500ms
+      start_period: 1m
+      retries: 3
+`,
+			healthCheck: &sloppy.HealthCheck{
+				Type:                 sloppy.String("HTTP"),
+				Path:                 sloppy.String("/health"),
+				Interval:             sloppy.Int(30),
+				Timeout:              sloppy.Int(2),
+				GracePeriod:          sloppy.Int(60),
+				MaxConsectiveFailure: sloppy.Int(3),
+			},
+		},
+		"service_healthy with shell test": {
+			condition: "service_healthy",
+			compose: `
+    ports:
+    - "80"
+    healthcheck:
+      test: wget -q --spider http://127.0.0.1/ping || exit 1
+`,
+			healthCheck: &sloppy.HealthCheck{
+				Type: sloppy.String("HTTP"),
+				Path: sloppy.String("/ping"),
+			},
+		},
+		"service_healthy without healthcheck": {
+			condition: "service_healthy",
+			err:       `service "web" depends on "api" being healthy, but "api" has no healthcheck`,
+		},
+		"service_healthy with unconvertible healthcheck": {
+			condition: "service_healthy",
+			compose: `
+    healthcheck:
+      test: ["CMD", "pg_isready"]
+`,
+			err: `service "web" depends on "api" being healthy, but its healthcheck can't be converted: ` +
+				`the healthcheck test "CMD pg_isready" can't be converted, only HTTP requests with curl or wget and nc -z are supported`,
+		},
+		"service_healthy with disabled healthcheck": {
+			condition: "service_healthy",
+			compose: `
+    healthcheck:
+      disable: true
+`,
+			err: `service "web" depends on "api" being healthy, but its healthcheck can't be converted: the healthcheck is disabled`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := []byte(`services:
+  web:
+    image: nginx
+    depends_on:
+      api:
+        condition: ` + c.condition + `
+  api:
+    image: api` + c.compose)
+			cf, err := converter.NewComposeFile(buf, "conditions")
+			if err != nil {
+				t.Fatal(err)
+			}
+			sf, err := converter.NewSloppyFile(cf)
+			if c.err != "" {
+				if err == nil || err.Error() != c.err {
+					t.Errorf("Expected %q, got %v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			linker := &converter.Linker{}
+			if err := linker.Resolve(cf, sf); err != nil {
+				t.Fatal(err)
+			}
+
+			apps := sf.Services["apps"]
+			if diff := cmp.Diff(apps["web"].App.Dependencies, []string{"../apps/api"}); diff != "" {
+				t.Errorf("Dependencies differ: (-got +want)\n%s", diff)
+			}
+			var healthChecks []*sloppy.HealthCheck
+			if c.healthCheck != nil {
+				healthChecks = []*sloppy.HealthCheck{c.healthCheck}
+			}
+			if diff := cmp.Diff(apps["api"].HealthChecks, healthChecks); diff != "" {
+				t.Errorf("Health checks differ: (-got +want)\n%s", diff)
+			}
+			if diff := cmp.Diff(sf.Warnings, c.warnings); diff != "" {
+				t.Errorf("Warnings differ: (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewSloppyFilePortModes(t *testing.T) {
+	buf := []byte(`services:
+  web:
+    image: nginx
+    ports:
+    - "9090"
+    - "80:8080"
+    - "8443:443"
+    - "9090:9090"
+  api:
+    image: api
+    ports:
+    - "3000"
+    - "9100"
+`)
+	cases := map[string]struct {
+		mode         string
+		port         map[string]*int
+		portMappings map[string][]*sloppy.PortMap
+		warnings     []string
+		err          error
+	}{
+		"first": {
+			port: map[string]*int{"web": sloppy.Int(8080), "api": sloppy.Int(3000)},
+			warnings: []string{
+				`Service "api": only the primary port 3000 is converted, the ports 9100 are dropped.`,
+				`Service "web": only the primary port 8080 is converted, the ports 9090, 443 are dropped.`,
+			},
+		},
+		"all": {
+			mode: converter.PortModeAll,
+			portMappings: map[string][]*sloppy.PortMap{
+				"web": {{Port: sloppy.Int(8080)}, {Port: sloppy.Int(9090)}, {Port: sloppy.Int(443)}},
+				"api": {{Port: sloppy.Int(3000)}, {Port: sloppy.Int(9100)}},
+			},
+		},
+		"invalid": {
+			mode: "some",
+			err:  converter.ErrPortMode,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			cf, err := converter.NewComposeFile(buf, "ports")
+			if err != nil {
+				t.Fatal(err)
+			}
+			sf, err := converter.NewSloppyFileWithOptions(cf, &converter.SloppyOptions{PortMode: c.mode})
+			if err != c.err {
+				t.Fatalf("Expected %v, got %v", c.err, err)
+			}
+			if err != nil {
+				return
+			}
+
+			for _, app := range []string{"web", "api"} {
+				if diff := cmp.Diff(sf.Services["apps"][app].Port, c.port[app]); diff != "" {
+					t.Errorf("Port of %s differs: (-got +want)\n%s", app, diff)
+				}
+				if diff := cmp.Diff(sf.Services["apps"][app].PortMappings, c.portMappings[app]); diff != "" {
+					t.Errorf("Port mappings of %s differ: (-got +want)\n%s", app, diff)
+				}
+			}
+			if diff := cmp.Diff(sf.Warnings, c.warnings); diff != "" {
+				t.Errorf("Warnings differ: (-got +want)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewSloppyFileCommands(t *testing.T) {
+	buf := []byte(`services:
+  shell:
+    image: node
+    command: npm run migrate && npm start | tee /var/log/app.log
+  variable:
+    image: alpine
+    entrypoint: /bin/sh -c
+    command: ["echo $$GREETING && sleep 1", "--", "it's"]
+  list:
+    image: alpine
+    entrypoint: ["/entrypoint.sh", "--port", "8080"]
+    command: echo $$HOME
+`)
+	cf, err := converter.NewComposeFile(buf, "commands")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf, err := converter.NewSloppyFile(cf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]*string{
+		"shell":    sloppy.String("npm run migrate && npm start | tee /var/log/app.log"),
+		"variable": sloppy.String(`/bin/sh -c 'echo $GREETING && sleep 1' -- 'it'\''s'`),
+		"list":     sloppy.String("/entrypoint.sh --port 8080 echo $HOME"),
+	}
+	for name, want := range expected {
+		if diff := cmp.Diff(sf.Services["apps"][name].Command, want); diff != "" {
+			t.Errorf("Command of %q differs: (-got +want)\n%s", name, diff)
+		}
+	}
+}
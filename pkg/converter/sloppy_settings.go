@@ -0,0 +1,317 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	sloppy "github.com/sloppyio/cli/pkg/api"
+)
+
+const (
+	// LabelPrefix starts the labels holding sloppy specific settings,
+	// e.g. `io.sloppy.domain.basicAuth`.
+	LabelPrefix = "io.sloppy."
+
+	settingSkip       = "skip"
+	settingVolumeSize = "volumeSize"
+	settingDomain     = "domain"
+	settingPort       = "port"
+)
+
+// sloppySettings are the sloppy specific settings of a service. Besides
+// skipping the service and the size of its volumes, they set the fields
+// of the app directly, named like in the sloppy.io API.
+type sloppySettings struct {
+	Skip       bool
+	VolumeSize string
+	// App holds the fields overriding the converted ones, all others
+	// are nil.
+	App *sloppy.App
+}
+
+// Returns the settings of the given service. The `x-sloppy` settings of
+// the project apply to all services, the `io.sloppy.*` labels of the
+// service override them and its own `x-sloppy` settings override both.
+func newSloppySettings(project *ComposeProject, service *ComposeService) (*sloppySettings, error) {
+	labels, err := labelSettings(service.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]interface{})
+	for _, block := range []map[string]interface{}{project.Sloppy, labels, service.Sloppy} {
+		for key, val := range block {
+			if key == settingDomain {
+				val = mergeDomainSetting(merged[key], val)
+			}
+			merged[key] = val
+		}
+	}
+
+	settings := &sloppySettings{App: &sloppy.App{}}
+	if val, ok := merged[settingSkip]; ok {
+		if settings.Skip, ok = val.(bool); !ok {
+			return nil, fmt.Errorf("x-sloppy: %s has to be a boolean", settingSkip)
+		}
+		delete(merged, settingSkip)
+	}
+	if val, ok := merged[settingVolumeSize]; ok {
+		settings.VolumeSize = fmt.Sprint(val)
+		delete(merged, settingVolumeSize)
+	}
+	// `port` is the shorthand of the yml format for a single port mapping
+	if val, ok := merged[settingPort]; ok {
+		merged["port_mappings"] = []interface{}{map[string]interface{}{"container_port": val}}
+		delete(merged, settingPort)
+	}
+
+	if err := checkSettingNames(reflect.TypeOf(settings.App), merged, ""); err != nil {
+		return nil, fmt.Errorf("x-sloppy: %v", err)
+	}
+	buf, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(buf, settings.App); err != nil {
+		return nil, fmt.Errorf("x-sloppy: %v", err)
+	}
+	return settings, nil
+}
+
+// Rejects settings which don't match the json name of a field of the
+// given type, nested settings are checked against the nested types.
+func checkSettingNames(t reflect.Type, val interface{}, path string) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		settings, ok := val.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for key, v := range settings {
+			field, ok := fieldByJSONName(t, key)
+			if !ok {
+				return fmt.Errorf("unknown setting %q", path+key)
+			}
+			if err := checkSettingNames(field.Type, v, path+key+"."); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		if list, ok := val.([]interface{}); ok {
+			for i, v := range list {
+				if err := checkSettingNames(t.Elem(), v, fmt.Sprintf("%s%d.", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func fieldByJSONName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); jsonFieldName(field) == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// Returns the settings of all services by their name.
+func loadSettings(project *ComposeProject) (map[string]*sloppySettings, error) {
+	settings := make(map[string]*sloppySettings)
+	for _, name := range project.ServiceNames() {
+		s, err := newSloppySettings(project, project.Services[name])
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %v", name, err)
+		}
+		settings[name] = s
+	}
+	return settings, nil
+}
+
+// Removes the services skipped by their settings like excluded ones.
+// Services with invalid settings are kept, converting them reports the
+// error.
+func (p *ComposeProject) skipServices() ([]string, error) {
+	var skipped, warnings []string
+	for _, name := range p.ServiceNames() {
+		s, err := newSloppySettings(p, p.Services[name])
+		if err == nil && s.Skip {
+			skipped = append(skipped, name)
+			warnings = append(warnings, fmt.Sprintf("Service %q is skipped by its sloppy settings.", name))
+		}
+	}
+	if len(skipped) == 0 {
+		return nil, nil
+	}
+	excluded, err := p.SelectServices(nil, skipped)
+	if err != nil {
+		return nil, err
+	}
+	return append(warnings, excluded...), nil
+}
+
+// Merges the domain settings, a string is the shorthand of the uri.
+func mergeDomainSetting(base, override interface{}) interface{} {
+	domain := make(map[string]interface{})
+	for _, val := range []interface{}{base, override} {
+		switch val := val.(type) {
+		case string:
+			domain["uri"] = val
+		case map[string]interface{}:
+			for k, v := range val {
+				domain[k] = v
+			}
+		}
+	}
+	return domain
+}
+
+// Turns the `io.sloppy.*` labels into settings, the dots of the label
+// key separate nested settings. Values are converted to the type of the
+// app field they set.
+func labelSettings(labels map[string]string) (map[string]interface{}, error) {
+	var keys []string
+	for key := range labels {
+		if strings.HasPrefix(key, LabelPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	settings := make(map[string]interface{})
+	for _, key := range keys {
+		path := strings.Split(strings.TrimPrefix(key, LabelPrefix), ".")
+		val, err := labelValue(path, labels[key])
+		if err != nil {
+			return nil, fmt.Errorf("label %q: %v", key, err)
+		}
+
+		m := settings
+		for _, name := range path[:len(path)-1] {
+			next, ok := m[name].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				m[name] = next
+			}
+			m = next
+		}
+		m[path[len(path)-1]] = val
+	}
+	return settings, nil
+}
+
+// Converts the label value to the type of the setting it sets.
+func labelValue(path []string, value string) (interface{}, error) {
+	kind := reflect.String
+	switch {
+	case len(path) == 1 && path[0] == settingSkip:
+		kind = reflect.Bool
+	case len(path) == 1 && path[0] == settingPort:
+		kind = reflect.Int
+	case len(path) == 1:
+		kind = fieldKind(reflect.TypeOf(sloppy.App{}), path[0])
+	case len(path) == 2 && path[0] == settingDomain:
+		kind = fieldKind(reflect.TypeOf(sloppy.Domain{}), path[1])
+	}
+
+	switch kind {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean %q", value)
+		}
+		return b, nil
+	case reflect.Int:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", value)
+		}
+		return i, nil
+	}
+	return value, nil
+}
+
+// Returns the kind of the field with the given json name, pointers are
+// dereferenced.
+func fieldKind(t reflect.Type, name string) reflect.Kind {
+	field, ok := fieldByJSONName(t, name)
+	if !ok {
+		return reflect.String
+	}
+	ft := field.Type
+	if ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+	return ft.Kind()
+}
+
+// Sets the fields of the settings on the app. The domain is merged with
+// the converted one and environment variables are added to the converted
+// ones.
+func (sf *SloppyFile) applySettings(app *SloppyApp, settings *sloppySettings, portMode string) {
+	dst := reflect.ValueOf(app.App).Elem()
+	src := reflect.ValueOf(settings.App).Elem()
+	for i := 0; i < src.NumField(); i++ {
+		if src.Field(i).IsNil() {
+			continue
+		}
+		switch src.Type().Field(i).Name {
+		case "Domain":
+			if app.App.Domain == nil {
+				app.App.Domain = &sloppy.Domain{}
+			}
+			mergePointerFields(reflect.ValueOf(app.App.Domain).Elem(), src.Field(i).Elem())
+		case "EnvVars":
+			if app.App.EnvVars == nil {
+				app.App.EnvVars = make(map[string]string)
+			}
+			for key, val := range settings.App.EnvVars {
+				app.App.EnvVars[key] = val
+			}
+		default:
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+
+	// keep the fields of the yml format in sync
+	if settings.App.Domain != nil {
+		app.Domain = &SloppyDomain{app.App.Domain}
+		if app.SSL == nil {
+			app.SSL = sloppy.Bool(true)
+		}
+	}
+	if settings.App.EnvVars != nil {
+		app.Env = nil
+		for key, val := range app.App.EnvVars {
+			app.Env = append(app.Env, map[string]string{key: val})
+		}
+	}
+	if portMappings := settings.App.PortMappings; portMappings != nil {
+		app.App.PortMappings = nil
+		app.Port = nil
+		app.PortMappings = nil
+		if portMode == PortModeAll {
+			app.PortMappings = portMappings
+		} else if len(portMappings) > 0 {
+			app.Port = portMappings[0].Port
+		}
+	}
+}
+
+// Sets the non-nil pointer fields of src on dst.
+func mergePointerFields(dst, src reflect.Value) {
+	for i := 0; i < src.NumField(); i++ {
+		if !src.Field(i).IsNil() {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
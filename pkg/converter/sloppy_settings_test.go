@@ -0,0 +1,167 @@
+package converter_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	sloppy "github.com/sloppyio/cli/pkg/api"
+	"github.com/sloppyio/sloppose/internal/test"
+	"github.com/sloppyio/sloppose/pkg/converter"
+)
+
+func TestNewSloppyFileSettings(t *testing.T) {
+	helper := test.NewHelper(t)
+	buf := []byte(`x-sloppy:
+  forceRollingDeploy: true
+  mem: 128
+services:
+  web:
+    image: nginx
+    domainname: web.sloppy.zone
+    ports:
+    - "80"
+    environment:
+      MODE: compose
+    labels:
+      io.sloppy.domain.redirectHttps: "true"
+      io.sloppy.domain.basicAuth: "admin:1234"
+      io.sloppy.instances: "2"
+      io.sloppy.mem: "256"
+    x-sloppy:
+      mem: 512
+      port: 8080
+      env:
+        MODE: sloppy
+  db:
+    image: postgres
+    volumes:
+    - data:/var/lib/postgresql/data
+    x-sloppy:
+      volumeSize: 16GB
+      forceRollingDeploy: false
+  builder:
+    build: .
+    labels:
+      io.sloppy.skip: "true"
+volumes:
+  data:
+`)
+	cf, err := converter.NewComposeFile(buf, "settings")
+	helper.Must(err)
+	sf, err := converter.NewSloppyFile(cf)
+	helper.Must(err)
+
+	apps := sf.Services["apps"]
+	if _, ok := apps["builder"]; ok {
+		t.Errorf("Expected the skipped service to be missing")
+	}
+	if diff := cmp.Diff(cf.Warnings, []string{`Service "builder" is skipped by its sloppy settings.`}); diff != "" {
+		t.Errorf("Warnings differ: (-got +want)\n%s", diff)
+	}
+	again, err := converter.NewSloppyFile(cf)
+	helper.Must(err)
+	if diff := cmp.Diff(again, sf); diff != "" {
+		t.Errorf("Converting twice differs: (-got +want)\n%s", diff)
+	}
+	web := apps["web"]
+	expectedDomain := &sloppy.Domain{
+		URI:           sloppy.String("web.sloppy.zone"),
+		RedirectHttps: sloppy.Bool(true),
+		BasicAuth:     sloppy.String("admin:1234"),
+	}
+	if diff := cmp.Diff(web.App.Domain, expectedDomain); diff != "" {
+		t.Errorf("Domain differs: (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(web.Memory, sloppy.Int(512)); diff != "" {
+		t.Errorf("Memory differs: (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(web.Instances, sloppy.Int(2)); diff != "" {
+		t.Errorf("Instances differ: (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(web.Port, sloppy.Int(8080)); diff != "" {
+		t.Errorf("Port differs: (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(web.Env, converter.SloppyEnvSlice{{"MODE": "sloppy"}}); diff != "" {
+		t.Errorf("Env differs: (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(web.ForceRollingDeploy, sloppy.Bool(true)); diff != "" {
+		t.Errorf("ForceRollingDeploy differs: (-got +want)\n%s", diff)
+	}
+
+	db := apps["db"]
+	if diff := cmp.Diff(db.ForceRollingDeploy, sloppy.Bool(false)); diff != "" {
+		t.Errorf("ForceRollingDeploy differs: (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(db.Memory, sloppy.Int(128)); diff != "" {
+		t.Errorf("Memory differs: (-got +want)\n%s", diff)
+	}
+	if diff := cmp.Diff(db.Volumes[0].Size, sloppy.String("16GB")); diff != "" {
+		t.Errorf("Volume size differs: (-got +want)\n%s", diff)
+	}
+
+	helper.ChdirTemp()
+	defer helper.ChdirTest()
+	writer := &converter.YAMLWriter{}
+	helper.Must(writer.WriteFile(sf, "settings.yml"))
+	out, err := ioutil.ReadFile("settings.yml")
+	helper.Must(err)
+	expected := `      domain:
+        basicAuth: admin:1234
+        redirectHttps: true
+        uri: web.sloppy.zone
+`
+	if !strings.Contains(string(out), expected) {
+		t.Errorf("Expected the domain options in the output, got\n%s", out)
+	}
+}
+
+func TestNewSloppyFileInvalidSettings(t *testing.T) {
+	cases := map[string]struct {
+		service string
+		err     string
+	}{
+		"unknown setting": {
+			service: `
+    x-sloppy:
+      memory: 512`,
+			err: `service "web": x-sloppy: unknown setting "memory"`,
+		},
+		"unknown nested setting": {
+			service: `
+    labels:
+      io.sloppy.domain.redirect: "true"`,
+			err: `service "web": x-sloppy: unknown setting "domain.redirect"`,
+		},
+		"invalid label": {
+			service: `
+    labels:
+      io.sloppy.forceRollingDeploy: "sometimes"`,
+			err: `service "web": label "io.sloppy.forceRollingDeploy": invalid boolean "sometimes"`,
+		},
+		"invalid skip": {
+			service: `
+    x-sloppy:
+      skip: "yes"`,
+			err: `service "web": x-sloppy: skip has to be a boolean`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := []byte(`services:
+  web:
+    image: nginx` + c.service + "\n")
+			cf, err := converter.NewComposeFile(buf, "settings")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = converter.NewSloppyFile(cf)
+			if err == nil || err.Error() != c.err {
+				t.Errorf("Expected %q, got %v", c.err, err)
+			}
+		})
+	}
+}
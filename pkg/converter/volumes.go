@@ -0,0 +1,87 @@
+package converter
+
+import (
+	"fmt"
+	"math"
+
+	sloppy "github.com/sloppyio/cli/pkg/api"
+	"github.com/sloppyio/sloppose/pkg/config"
+)
+
+const (
+	// LabelVolumeSize sets the size of a top-level volume on sloppy.io.
+	LabelVolumeSize = "io.sloppy.size"
+	// driverOptVolumeSize is the volume driver option holding its size.
+	driverOptVolumeSize = "size"
+)
+
+// Converts the mounts of a service to sloppy volumes. Named and anonymous
+// volumes become sloppy volumes, bind mounts and tmpfs mounts can't be
+// represented and are reported.
+func (sf *SloppyFile) convertVolumes(name string, mounts []config.ServiceVolumeConfig, project *ComposeProject, defaultSize string) ([]*sloppy.Volume, error) {
+	var volumes []*sloppy.Volume
+	for _, mount := range mounts {
+		switch mount.Type {
+		case config.VolumeTypeBind:
+			sf.Warnings = append(sf.Warnings, fmt.Sprintf(
+				"Service %q: the bind mount of %q at %q is skipped, the host directory doesn't exist on sloppy.io.",
+				name, mount.Source, mount.Target))
+			continue
+		case config.VolumeTypeTmpfs:
+			sf.Warnings = append(sf.Warnings, fmt.Sprintf(
+				"Service %q: the tmpfs mount at %q is skipped, sloppy.io doesn't support them.", name, mount.Target))
+			continue
+		}
+
+		size := defaultSize
+		if mount.Source != "" {
+			volume, ok := project.Volumes[mount.Source]
+			if !ok {
+				sf.Warnings = append(sf.Warnings, fmt.Sprintf(
+					"Service %q refers to the undeclared volume %q.", name, mount.Source))
+			} else {
+				if volume.External {
+					sf.Warnings = append(sf.Warnings, fmt.Sprintf(
+						"Service %q: the external volume %q is created empty on sloppy.io.", name, mount.Source))
+				}
+				if s := volumeSize(volume); s != "" {
+					size = s
+				}
+			}
+		}
+
+		target := mount.Target
+		v := &sloppy.Volume{Path: &target}
+		if size != "" {
+			converted, err := convertVolumeSize(size)
+			if err != nil {
+				return nil, fmt.Errorf("service %q: volume %q: %v", name, mount.Target, err)
+			}
+			v.Size = &converted
+		}
+		volumes = append(volumes, v)
+	}
+	return volumes, nil
+}
+
+// Returns the size of the volume given by its labels or driver options.
+func volumeSize(volume *ProjectVolume) string {
+	if size := volume.Labels[LabelVolumeSize]; size != "" {
+		return size
+	}
+	return volume.DriverOpts[driverOptVolumeSize]
+}
+
+// Converts sizes like `512m`, `10GiB` or `8GB` to the whole gigabytes
+// sloppy.io expects, rounded up.
+func convertVolumeSize(size string) (string, error) {
+	bytes, err := parseByteSize(size)
+	if err != nil {
+		return "", fmt.Errorf("invalid volume size %q", size)
+	}
+	gigabytes := math.Ceil(bytes / (1024 * mebibyte))
+	if gigabytes < 1 {
+		gigabytes = 1
+	}
+	return fmt.Sprintf("%dGB", int(gigabytes)), nil
+}
@@ -0,0 +1,101 @@
+package converter_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	sloppy "github.com/sloppyio/cli/pkg/api"
+	"github.com/sloppyio/sloppose/pkg/converter"
+)
+
+func TestNewSloppyFileVolumes(t *testing.T) {
+	buf := []byte(`services:
+  db:
+    image: postgres
+    volumes:
+    - data:/var/lib/postgresql/data
+    - type: volume
+      source: backups
+      target: /backups
+    - /var/cache
+    - ./init.sql:/docker-entrypoint-initdb.d/init.sql:ro
+    - type: tmpfs
+      target: /tmp
+    - logs:/var/log
+    - shared:/shared
+    - archive:/archive
+volumes:
+  data:
+    driver_opts:
+      size: 512m
+  backups:
+    labels:
+      io.sloppy.size: 20GiB
+  shared:
+    external: true
+  archive:
+    external:
+      name: legacy_archive
+    driver_opts:
+      size: 2147483648
+`)
+	cf, err := converter.NewComposeFile(buf, "volumes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf, err := converter.NewSloppyFileWithOptions(cf, &converter.SloppyOptions{VolumeSize: "8GB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*sloppy.Volume{
+		{Path: sloppy.String("/var/lib/postgresql/data"), Size: sloppy.String("1GB")},
+		{Path: sloppy.String("/backups"), Size: sloppy.String("20GB")},
+		{Path: sloppy.String("/var/cache"), Size: sloppy.String("8GB")},
+		{Path: sloppy.String("/var/log"), Size: sloppy.String("8GB")},
+		{Path: sloppy.String("/shared"), Size: sloppy.String("8GB")},
+		{Path: sloppy.String("/archive"), Size: sloppy.String("2GB")},
+	}
+	if diff := cmp.Diff(sf.Services["apps"]["db"].Volumes, expected); diff != "" {
+		t.Errorf("Result differs: (-got +want)\n%s", diff)
+	}
+
+	warnings := []string{
+		`Service "db": the bind mount of "./init.sql" at "/docker-entrypoint-initdb.d/init.sql" is skipped, the host directory doesn't exist on sloppy.io.`,
+		`Service "db": the tmpfs mount at "/tmp" is skipped, sloppy.io doesn't support them.`,
+		`Service "db" refers to the undeclared volume "logs".`,
+		`Service "db": the external volume "shared" is created empty on sloppy.io.`,
+		`Service "db": the external volume "archive" is created empty on sloppy.io.`,
+	}
+	if diff := cmp.Diff(sf.Warnings, warnings); diff != "" {
+		t.Errorf("Warnings differ: (-got +want)\n%s", diff)
+	}
+}
+
+func TestNewSloppyFileInvalidVolumeSize(t *testing.T) {
+	buf := []byte(`services:
+  db:
+    image: postgres
+    volumes:
+    - data:/data
+volumes:
+  data:
+    labels:
+      io.sloppy.size: lots
+`)
+	cf, err := converter.NewComposeFile(buf, "volumes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = converter.NewSloppyFile(cf)
+	expected := `service "db": volume "/data": invalid volume size "lots"`
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+
+	_, err = converter.NewSloppyFileWithOptions(cf, &converter.SloppyOptions{VolumeSize: "big"})
+	if err == nil || err.Error() != `invalid volume size "big"` {
+		t.Errorf("Expected an error due to the invalid default size, got %v", err)
+	}
+}
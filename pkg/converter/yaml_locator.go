@@ -0,0 +1,267 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// yamlLocator maps element paths like `services.web.ports[1]` to their
+// position within a yaml document. The vendored yaml parser doesn't expose
+// positions, so the locator reads the text itself. It understands block
+// mappings and sequences, anchors and tags in front of them and flow
+// sequences spanning one or more lines, which covers the usual compose
+// files. Elements it can't find are reported at the position of their
+// closest known parent, which applies to:
+//   - elements merged or referred to by aliases (`<<: *default`), they are
+//     reported at the mapping or key referring to the alias
+//   - the keys of flow mappings (`{target: 80}`), reported at the mapping
+//   - elements of flow collections nested in flow collections
+type yamlLocator struct {
+	lines     []string
+	positions map[string][2]int
+}
+
+func newYAMLLocator(source []byte) *yamlLocator {
+	l := &yamlLocator{
+		lines:     strings.Split(strings.Replace(string(source), "\t", " ", -1), "\n"),
+		positions: make(map[string][2]int),
+	}
+	l.parseNode("", 0, -1, false)
+	return l
+}
+
+// Locate returns the line and column (both starting at 1) of the given
+// path, both are zero if not even a parent element could be found.
+func (l *yamlLocator) Locate(path string) (line, column int) {
+	for path != "" {
+		if pos, ok := l.positions[path]; ok {
+			return pos[0], pos[1]
+		}
+		path = parentPath(path)
+	}
+	return 0, 0
+}
+
+// Parses the block node starting at line i, its lines have to be indented
+// more than parentIndent. Sequences of mapping values may be indented like
+// their key. Returns the index of the first line after the node.
+func (l *yamlLocator) parseNode(path string, i, parentIndent int, mappingValue bool) int {
+	i = l.nextLine(i)
+	if i >= len(l.lines) {
+		return i
+	}
+	indent, content := l.split(i)
+	if indent < parentIndent || (indent == parentIndent && !(mappingValue && isSequenceEntry(content))) {
+		return i
+	}
+
+	if isSequenceEntry(content) {
+		return l.parseSequence(path, i, indent)
+	}
+	if _, _, ok := splitMappingKey(content); ok {
+		return l.parseMapping(path, i, indent)
+	}
+	// multi line scalar
+	return l.skip(i+1, parentIndent)
+}
+
+func (l *yamlLocator) parseSequence(path string, i, indent int) int {
+	for n := 0; ; n++ {
+		i = l.nextLine(i)
+		if i >= len(l.lines) {
+			return i
+		}
+		ind, content := l.split(i)
+		if ind != indent || !isSequenceEntry(content) {
+			return i
+		}
+		itemPath := fmt.Sprintf("%s[%d]", path, n)
+		l.positions[itemPath] = [2]int{i + 1, indent + 1}
+
+		// the entry content is parsed as if it was on a line of its own
+		rest := stripNodeProperties(strings.TrimLeft(content[1:], " "))
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			i = l.parseNode(itemPath, i+1, indent, false)
+			continue
+		}
+		column := indent + len(content) - len(rest)
+		l.lines[i] = strings.Repeat(" ", column) + rest
+		l.positions[itemPath] = [2]int{i + 1, column + 1}
+		i = l.parseNode(itemPath, i, indent, false)
+	}
+}
+
+func (l *yamlLocator) parseMapping(path string, i, indent int) int {
+	for {
+		i = l.nextLine(i)
+		if i >= len(l.lines) {
+			return i
+		}
+		ind, content := l.split(i)
+		if ind != indent {
+			return i
+		}
+		key, value, ok := splitMappingKey(content)
+		if !ok {
+			return i
+		}
+		keyPath := joinPath(path, key)
+		l.positions[keyPath] = [2]int{i + 1, indent + 1}
+
+		value = stripNodeProperties(strings.TrimSpace(value))
+		switch {
+		case value == "" || strings.HasPrefix(value, "#"):
+			i = l.parseNode(keyPath, i+1, indent, true)
+		case strings.HasPrefix(value, "["):
+			end := l.parseFlowSequence(keyPath, i, strings.LastIndex(l.lines[i], value))
+			i = l.skip(end+1, indent)
+		default:
+			// scalars, block scalars and flow mappings
+			i = l.skip(i+1, indent)
+		}
+	}
+}
+
+// Records the items of a flow sequence like `["80:80", "443:443"]`
+// starting at the given line and column, it may span multiple lines.
+// Nested flow collections are skipped. Returns the index of the line the
+// sequence ends on.
+func (l *yamlLocator) parseFlowSequence(path string, line, start int) int {
+	n, depth := 0, 0
+	var quote byte
+	itemStart := true
+	for ; line < len(l.lines); line, start = line+1, 0 {
+		text := l.lines[line]
+		for pos := start; pos < len(text); pos++ {
+			c := text[pos]
+			switch {
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+				continue
+			case c == '#' && (pos == 0 || text[pos-1] == ' '):
+				// the rest of the line is a comment
+				pos = len(text)
+				continue
+			case c == '[' || c == '{':
+				depth++
+				if depth == 1 {
+					continue
+				}
+			case c == ']' || c == '}':
+				depth--
+				if depth == 0 {
+					return line
+				}
+			case c == ',' && depth == 1:
+				n++
+				itemStart = true
+				continue
+			case c == ' ' || c == '\r':
+				continue
+			}
+			if c == '"' || c == '\'' {
+				quote = c
+			}
+			if itemStart && depth >= 1 {
+				l.positions[fmt.Sprintf("%s[%d]", path, n)] = [2]int{line + 1, pos + 1}
+				itemStart = false
+			}
+		}
+	}
+	return line
+}
+
+// Returns the index of the first line at i or after it which is neither
+// empty nor a comment nor a document marker.
+func (l *yamlLocator) nextLine(i int) int {
+	for ; i < len(l.lines); i++ {
+		content := strings.TrimSpace(l.lines[i])
+		if content != "" && !strings.HasPrefix(content, "#") && content != "---" && content != "..." {
+			return i
+		}
+	}
+	return i
+}
+
+// Skips all lines indented more than the given indentation.
+func (l *yamlLocator) skip(i, indent int) int {
+	for {
+		next := l.nextLine(i)
+		if next >= len(l.lines) {
+			return next
+		}
+		if ind, _ := l.split(next); ind <= indent {
+			return next
+		}
+		i = next + 1
+	}
+}
+
+func (l *yamlLocator) split(i int) (indent int, content string) {
+	line := strings.TrimRight(l.lines[i], " \r")
+	content = strings.TrimLeft(line, " ")
+	return len(line) - len(content), content
+}
+
+// Removes the anchor and tag in front of a node, e.g. `&default` or
+// `!!str`, the rest of the line is returned.
+func stripNodeProperties(value string) string {
+	for strings.HasPrefix(value, "&") || strings.HasPrefix(value, "!") {
+		end := strings.IndexByte(value, ' ')
+		if end == -1 {
+			return ""
+		}
+		value = strings.TrimLeft(value[end:], " ")
+	}
+	return value
+}
+
+func isSequenceEntry(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ")
+}
+
+// Splits `key: value` lines, keys may be quoted.
+func splitMappingKey(content string) (key, value string, ok bool) {
+	if content == "" {
+		return "", "", false
+	}
+	if c := content[0]; c == '"' || c == '\'' {
+		end := strings.IndexByte(content[1:], c)
+		if end == -1 {
+			return "", "", false
+		}
+		key, rest := content[1:end+1], content[end+2:]
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", false
+		}
+		return key, rest[1:], true
+	}
+	if strings.HasPrefix(content, "[") || strings.HasPrefix(content, "{") {
+		return "", "", false
+	}
+
+	for pos := 0; pos < len(content); pos++ {
+		if content[pos] == ':' && (pos+1 == len(content) || content[pos+1] == ' ') {
+			return strings.TrimSpace(content[:pos]), content[pos+1:], true
+		}
+		if content[pos] == ' ' && pos+1 < len(content) && content[pos+1] == '#' {
+			break
+		}
+	}
+	return "", "", false
+}
+
+// Returns `services.web` for `services.web.ports[1]` and `services.web.ports`.
+func parentPath(path string) string {
+	if strings.HasSuffix(path, "]") {
+		if i := strings.LastIndex(path, "["); i != -1 {
+			return path[:i]
+		}
+	}
+	if i := strings.LastIndex(path, "."); i != -1 {
+		return path[:i]
+	}
+	return ""
+}
@@ -3,6 +3,7 @@ package converter_test
 import (
 	"flag"
 	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
 
@@ -14,10 +15,13 @@ import (
 
 var updateFlag = flag.Bool("update", false, "go test -update")
 
-func init() {
+// The flags are parsed in TestMain, go test registers its own flags after
+// the init functions of the package since Go 1.13.
+func TestMain(m *testing.M) {
 	if !flag.Parsed() {
 		flag.Parse()
 	}
+	os.Exit(m.Run())
 }
 
 func TestYAMLWriter_WriteFile(t *testing.T) {